@@ -3,10 +3,15 @@ package cfg
 import (
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/yamlnode"
 )
 
 const TypeSoftDependency = "soft"
@@ -21,6 +26,9 @@ type Attributes struct {
 // dependencies of an Application.
 type Config struct {
 	AppName string `yaml:"name"`
+	// DeployDuration is the optional estimated duration of deploying the
+	// app, e.g. "5m30s".
+	DeployDuration time.Duration `yaml:"deploy_duration,omitempty"`
 	// Key of Dependencise must either be "Default" (case-insensitive) a
 	// string existing in Targets
 	// Dependencies is map of map[DISTRIBUTION-NAME]map[DEPENDS-ON-APP-NAME]Attributes
@@ -69,6 +77,66 @@ func FromFile(path string) (*Config, error) {
 	return Unmarshal(f)
 }
 
+// Marshal YAML encodes a to w. Hard dependencies are written as null
+// values, other dependency attributes in flow style.
+func (a *Config) Marshal(w io.Writer) error {
+	doc := yaml.Node{Kind: yaml.MappingNode}
+	doc.Content = append(doc.Content, yamlnode.String("name"), yamlnode.String(a.AppName))
+	if a.DeployDuration != 0 {
+		doc.Content = append(doc.Content, yamlnode.String("deploy_duration"), yamlnode.String(a.DeployDuration.String()))
+	}
+
+	distrs := yaml.Node{Kind: yaml.MappingNode}
+	for _, distr := range slices.Sorted(maps.Keys(a.Dependencies)) {
+		apps := yaml.Node{Kind: yaml.MappingNode}
+		for _, app := range slices.Sorted(maps.Keys(a.Dependencies[distr])) {
+			attr := a.Dependencies[distr][app]
+			if attr == nil || attr.Type == TypeHardDependency {
+				apps.Content = append(apps.Content,
+					yamlnode.String(app),
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "~"},
+				)
+				continue
+			}
+
+			apps.Content = append(apps.Content,
+				yamlnode.String(app),
+				&yaml.Node{
+					Kind:    yaml.MappingNode,
+					Style:   yaml.FlowStyle,
+					Content: []*yaml.Node{yamlnode.String("type"), yamlnode.String(attr.Type)},
+				},
+			)
+		}
+		distrs.Content = append(distrs.Content, yamlnode.String(distr), &apps)
+	}
+	doc.Content = append(doc.Content, yamlnode.String("dependencies"), &distrs)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// ToFile YAML encodes a and writes it to the file at path. If the file
+// exists, it is overwritten.
+func (a *Config) ToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := a.Marshal(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
 // setDefaults sets the Attributes.Type default value in a to
 // TypeDefaultDependency, if the Type field or the pointer to the Attributes
 // struct is unset.
@@ -92,6 +160,10 @@ func (a *Config) Validate() error {
 		return fmt.Errorf("name is empty or contains only whitespaces: %q", a.AppName)
 	}
 
+	if a.DeployDuration < 0 {
+		return fmt.Errorf("deploy_duration is negative: %s", a.DeployDuration)
+	}
+
 	if len(a.Dependencies) == 0 {
 		return fmt.Errorf("dependencies map is empty, expecting at least 1 distribution key")
 	}
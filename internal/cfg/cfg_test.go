@@ -1,8 +1,10 @@
 package cfg
 
 import (
+	"bytes"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -77,3 +79,49 @@ dependencies:
 	assert.Equal(t, "hard", stg["fax-service"].Type)
 	assert.Equal(t, "hard", stg["messenger-service"].Type)
 }
+
+func TestUnmarshalDeployDuration(t *testing.T) {
+	yml := `
+name: "testapp"
+deploy_duration: 5m30s
+dependencies:
+  production: ~
+`
+
+	cfg, err := Unmarshal(strings.NewReader(yml))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, 5*time.Minute+30*time.Second, cfg.DeployDuration)
+}
+
+func TestMarshal(t *testing.T) {
+	cfg := Config{
+		AppName:        "testapp",
+		DeployDuration: 90 * time.Second,
+		Dependencies: map[string]map[string]*Attributes{
+			"production": {
+				"sms-service":       {Type: TypeHardDependency},
+				"mail-service":      nil,
+				"telephone-service": {Type: TypeSoftDependency},
+			},
+			"testing": {},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cfg.Marshal(&buf))
+	assert.Equal(t, `name: testapp
+deploy_duration: 1m30s
+dependencies:
+  production:
+    mail-service: ~
+    sms-service: ~
+    telephone-service: {type: soft}
+  testing: {}
+`, buf.String())
+
+	decoded, err := Unmarshal(&buf)
+	require.NoError(t, err)
+	cfg.Dependencies["production"]["mail-service"] = &Attributes{Type: TypeHardDependency}
+	assert.Equal(t, &cfg, decoded)
+}
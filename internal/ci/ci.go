@@ -0,0 +1,246 @@
+// Package ci generates CI pipeline definitions that deploy the apps of a
+// distribution in dependency order.
+package ci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/yamlnode"
+)
+
+// Supported platforms.
+const (
+	PlatformGitHub = "github"
+	PlatformGitLab = "gitlab"
+)
+
+// Platforms are the supported platforms.
+var Platforms = []string{PlatformGitHub, PlatformGitLab}
+
+// gitLabReservedKeys are top-level keys of GitLab CI pipelines that do not
+// define jobs.
+var gitLabReservedKeys = []string{
+	"after_script", "before_script", "cache", "default", "image", "include",
+	"services", "spec", "stages", "variables", "workflow",
+}
+
+// TemplateData are the fields that are available in the job and job ID
+// templates.
+type TemplateData struct {
+	App          string
+	Distribution string
+	// Wave is the number of the deployment wave of the app, starting at 1.
+	Wave int
+	// Needs are the job IDs of the hard dependencies of the app, they are
+	// empty when the job ID template is rendered.
+	Needs []string
+}
+
+// Generator creates pipeline definitions with one job per app.
+type Generator struct {
+	// Platform is one of Platforms.
+	Platform string
+	// JobTemplate is rendered for every app, the result must be a YAML
+	// mapping that defines the job.
+	JobTemplate *template.Template
+	// JobID is rendered for every app to get the ID of its job.
+	JobID *template.Template
+	// Base is the YAML document the jobs are added to. If nil, an empty
+	// pipeline is used, for GitHub it is triggered via workflow_dispatch.
+	Base *yaml.Node
+}
+
+// Generate returns the pipeline definition for the apps of the distribution.
+// Each job needs the jobs of the hard dependencies of its app. If the job
+// template already contains a needs key, the job IDs it does not contain yet
+// are appended to it.
+// For GitHub the jobs are added to the "jobs" key of the workflow, for GitLab
+// they are added as top-level keys, an error is returned if a job ID is a
+// reserved GitLab keyword. Jobs of the base document are kept, an
+// error is returned if a generated job has the same ID.
+// If apps is not empty, only jobs for the given apps and their dependencies
+// are created.
+func (g *Generator) Generate(composition *deps.Composition, distribution string, apps ...string) (*yaml.Node, error) {
+	waves, err := composition.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := g.baseDocument(distribution)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+
+	jobIDs := map[string]string{}
+	seenIDs := map[string]string{}
+	for i, wave := range waves {
+		for _, app := range wave {
+			id, err := render(g.JobID, &TemplateData{App: app, Distribution: distribution, Wave: i + 1})
+			if err != nil {
+				return nil, fmt.Errorf("rendering job id of %s failed: %w", app, err)
+			}
+
+			switch g.Platform {
+			case PlatformGitHub:
+				id = sanitizeGitHubJobID(id)
+			case PlatformGitLab:
+				if slices.Contains(gitLabReservedKeys, id) {
+					return nil, fmt.Errorf("job id %q of %s is a reserved GitLab keyword", id, app)
+				}
+				if strings.HasPrefix(id, ".") {
+					return nil, fmt.Errorf("job id %q of %s starts with a dot, GitLab treats it as hidden job", id, app)
+				}
+			}
+
+			if other, exists := seenIDs[id]; exists {
+				return nil, fmt.Errorf("the apps %s and %s have the same job id %q", other, app, id)
+			}
+			seenIDs[id] = app
+			jobIDs[app] = id
+		}
+	}
+
+	jobs := root
+	if g.Platform == PlatformGitHub {
+		jobs = yamlnode.Get(root, "jobs")
+		if jobs == nil {
+			jobs = &yaml.Node{Kind: yaml.MappingNode}
+			yamlnode.Set(root, "jobs", jobs)
+		}
+		if jobs.Kind != yaml.MappingNode {
+			return nil, errors.New("jobs key of the base document is not a mapping")
+		}
+	}
+
+	for i, wave := range waves {
+		for _, app := range wave {
+			data := TemplateData{App: app, Distribution: distribution, Wave: i + 1, Needs: []string{}}
+			for _, hd := range composition.Distribution[distribution][app].HardDeps {
+				data.Needs = append(data.Needs, jobIDs[hd])
+			}
+
+			job, err := g.job(&data)
+			if err != nil {
+				return nil, fmt.Errorf("creating job for %s failed: %w", app, err)
+			}
+
+			if yamlnode.Get(jobs, jobIDs[app]) != nil {
+				return nil, fmt.Errorf("job %q is already defined in the base document", jobIDs[app])
+			}
+			yamlnode.Set(jobs, jobIDs[app], job)
+		}
+	}
+
+	return doc, nil
+}
+
+func (g *Generator) baseDocument(distribution string) (*yaml.Node, error) {
+	if g.Base != nil {
+		if g.Base.Kind != yaml.DocumentNode || len(g.Base.Content) != 1 || g.Base.Content[0].Kind != yaml.MappingNode {
+			return nil, errors.New("base document must be a YAML mapping")
+		}
+		return g.Base, nil
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	if g.Platform == PlatformGitHub {
+		yamlnode.Set(root, "name", yamlnode.String("Deploy "+distribution))
+		on := &yaml.Node{Kind: yaml.MappingNode}
+		yamlnode.Set(on, "workflow_dispatch", &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle})
+		yamlnode.Set(root, "on", on)
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}, nil
+}
+
+// job renders the job template and adds the needs.
+func (g *Generator) job(data *TemplateData) (*yaml.Node, error) {
+	buf, err := render(g.JobTemplate, data)
+	if err != nil {
+		return nil, fmt.Errorf("rendering job template failed: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(buf), &doc); err != nil {
+		return nil, fmt.Errorf("parsing rendered job template failed: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("rendered job template is not a YAML mapping")
+	}
+	job := doc.Content[0]
+
+	if len(data.Needs) == 0 {
+		return job, nil
+	}
+
+	needs := yamlnode.Get(job, "needs")
+	switch {
+	case needs == nil:
+		needs = &yaml.Node{Kind: yaml.SequenceNode}
+		yamlnode.Set(job, "needs", needs)
+	case needs.Kind == yaml.ScalarNode:
+		// GitHub allows a single job ID as needs value
+		needs = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{needs}}
+		yamlnode.Set(job, "needs", needs)
+	case needs.Kind != yaml.SequenceNode:
+		return nil, errors.New("needs key of the rendered job template is not a sequence or a job id")
+	}
+
+	existing := map[string]struct{}{}
+	for _, n := range needs.Content {
+		existing[n.Value] = struct{}{}
+	}
+	for _, n := range data.Needs {
+		if _, exists := existing[n]; exists {
+			continue
+		}
+		existing[n] = struct{}{}
+		needs.Content = append(needs.Content, yamlnode.String(n))
+	}
+
+	return job, nil
+}
+
+func render(tmpl *template.Template, data *TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sanitizeGitHubJobID replaces characters that are not allowed in GitHub job
+// IDs with underscores. IDs must start with a letter or underscore and
+// only contain alphanumeric characters, dashes and underscores. If id starts
+// with a digit or dash, an underscore is prepended.
+func sanitizeGitHubJobID(id string) string {
+	res := make([]rune, 0, len(id)+1)
+	for _, r := range id {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_':
+			res = append(res, r)
+		case (r >= '0' && r <= '9') || r == '-':
+			if len(res) == 0 {
+				res = append(res, '_')
+			}
+			res = append(res, r)
+		default:
+			res = append(res, '_')
+		}
+	}
+
+	if len(res) == 0 {
+		return "_"
+	}
+
+	return string(res)
+}
@@ -0,0 +1,152 @@
+package ci
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+func TestGenerateGitHubWorkflow(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("prd", "1-api", &deps.Dependencies{HardDeps: []string{"db.main"}, SoftDeps: []string{"cache"}})
+	comp.Add("prd", "db.main", &deps.Dependencies{})
+	comp.Add("prd", "cache", &deps.Dependencies{})
+
+	gen := Generator{
+		Platform: PlatformGitHub,
+		JobTemplate: template.Must(template.New("").Parse(
+			"runs-on: ubuntu-latest\nneeds: [build]\nenv:\n  WAVE: \"{{.Wave}}\"\n",
+		)),
+		JobID: template.Must(template.New("").Parse("{{.App}}")),
+	}
+
+	doc, err := gen.Generate(comp, "prd")
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	var workflow struct {
+		Jobs map[string]struct {
+			Needs []string          `yaml:"needs"`
+			Env   map[string]string `yaml:"env"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &workflow))
+
+	require.Len(t, workflow.Jobs, 3)
+	assert.Equal(t, []string{"build", "db_main"}, workflow.Jobs["_1-api"].Needs)
+	assert.Equal(t, "2", workflow.Jobs["_1-api"].Env["WAVE"])
+	assert.Equal(t, []string{"build"}, workflow.Jobs["cache"].Needs)
+	assert.Equal(t, []string{"build"}, workflow.Jobs["db_main"].Needs)
+}
+
+func TestGenerateGitHubWorkflowKeepsBaseJobs(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("prd", "api", &deps.Dependencies{HardDeps: []string{"db"}})
+	comp.Add("prd", "db", &deps.Dependencies{})
+
+	var base yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+name: deploy
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+`), &base))
+
+	gen := Generator{
+		Platform: PlatformGitHub,
+		JobTemplate: template.Must(template.New("").Parse(
+			"runs-on: ubuntu-latest\nneeds: build\n{{if .Needs}}env:\n  NEEDS: \"{{index .Needs 0}}\"\n{{end}}",
+		)),
+		JobID: template.Must(template.New("").Parse("deploy-{{.App}}")),
+		Base:  &base,
+	}
+
+	doc, err := gen.Generate(comp, "prd")
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	var workflow struct {
+		Jobs map[string]struct {
+			Needs any `yaml:"needs"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &workflow))
+
+	require.Len(t, workflow.Jobs, 3)
+	assert.Contains(t, workflow.Jobs, "build")
+	assert.Equal(t, []any{"build", "deploy-db"}, workflow.Jobs["deploy-api"].Needs)
+	// jobs without dependencies are not modified
+	assert.Equal(t, "build", workflow.Jobs["deploy-db"].Needs)
+
+	gen.JobID = template.Must(template.New("").Parse("{{if eq .App \"db\"}}build{{else}}{{.App}}{{end}}"))
+	require.NoError(t, yaml.Unmarshal([]byte("jobs:\n  build: {}\n"), &base))
+	_, err = gen.Generate(comp, "prd")
+	assert.ErrorContains(t, err, "already defined in the base document")
+}
+
+func TestGenerateNeedsAreNotDuplicated(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("prd", "api", &deps.Dependencies{HardDeps: []string{"db"}})
+	comp.Add("prd", "db", &deps.Dependencies{})
+
+	gen := Generator{
+		Platform: PlatformGitHub,
+		JobTemplate: template.Must(template.New("").Parse(
+			"runs-on: ubuntu-latest\n{{if .Needs}}needs: {{.Needs}}\n{{end}}",
+		)),
+		JobID: template.Must(template.New("").Parse("{{.App}}")),
+	}
+
+	doc, err := gen.Generate(comp, "prd")
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	var workflow struct {
+		Jobs map[string]struct {
+			Needs []string `yaml:"needs"`
+		} `yaml:"jobs"`
+	}
+	require.NoError(t, yaml.Unmarshal(out, &workflow))
+	assert.Equal(t, []string{"db"}, workflow.Jobs["api"].Needs)
+}
+
+func TestGenerateGitLabRejectsReservedJobIDs(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("prd", "variables", &deps.Dependencies{})
+
+	gen := Generator{
+		Platform:    PlatformGitLab,
+		JobTemplate: template.Must(template.New("").Parse("script: [deploy]\n")),
+		JobID:       template.Must(template.New("").Parse("{{.App}}")),
+	}
+
+	_, err := gen.Generate(comp, "prd")
+	require.ErrorContains(t, err, "reserved GitLab keyword")
+
+	gen.JobID = template.Must(template.New("").Parse(".{{.App}}"))
+	_, err = gen.Generate(comp, "prd")
+	require.ErrorContains(t, err, "hidden job")
+
+	gen.JobID = template.Must(template.New("").Parse("deploy-{{.App}}"))
+	doc, err := gen.Generate(comp, "prd")
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(doc)
+	require.NoError(t, err)
+
+	var pipeline map[string]any
+	require.NoError(t, yaml.Unmarshal(out, &pipeline))
+	assert.Contains(t, pipeline, "deploy-variables")
+}
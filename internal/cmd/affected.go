@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const affectedShortHelp = "Generate a deployment order for the apps affected by changed files."
+
+var affectedLongHelp = affectedShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  CHANGED-FILES	- File containing the paths of changed files, one per line.
+		  If omitted or "-", the paths are read from stdin.
+
+The paths of the changed files must be relative to ROOT-DIR, like the output of
+"git diff --name-only" when ROOT-DIR is the root of the git repository.
+Each path is mapped to the app that owns it. An app owns all files in the
+directory that contains its dependency configuration file. If the directory is
+nested in another app's directory, the files are owned by the app with the
+closest directory. Paths that are not owned by any app are ignored.
+
+The dependency order is generated only for the owning apps and, when requested,
+their dependents or dependencies.
+
+`+descrDependencyFileNames)
+
+type affectedCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	output           orderOutput
+	withDependents   bool
+	withDependencies bool
+
+	src          string
+	distr        string
+	changedFiles string
+	srcType      fs.PathType
+}
+
+func newAffectedCmd(root *rootCmd) *affectedCmd {
+	cmd := affectedCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "affected ROOT-DIR|DEP-TREE-FILE DISTRIBUTION [CHANGED-FILES]",
+			Short: affectedShortHelp,
+			Long:  affectedLongHelp,
+			Args:  cobra.RangeArgs(2, 3),
+		},
+	}
+
+	cmd.output.addFlags(cmd.Command)
+	cmd.Flags().BoolVar(
+		&cmd.withDependents, "with-dependents", false,
+		"include all apps that directly or transitively depend on the affected apps",
+	)
+	cmd.Flags().BoolVar(
+		&cmd.withDependencies, "with-dependencies", false,
+		"include all recursive dependencies of the affected apps",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if err := cmd.output.validate(); err != nil {
+			return err
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		if len(args) == 3 {
+			cmd.changedFiles = args[2]
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *affectedCmd) run(cc *cobra.Command, _ []string) error {
+	paths, err := c.readChangedFiles(cc)
+	if err != nil {
+		return fmt.Errorf("reading changed files failed: %w", err)
+	}
+
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	apps, _, err := composition.OwningApps(c.distr, c.root.cfgName, paths)
+	if err != nil {
+		return err
+	}
+
+	apps, err = c.extend(composition, apps)
+	if err != nil {
+		return err
+	}
+
+	restricted, err := composition.Restrict(c.distr, apps)
+	if err != nil {
+		return err
+	}
+
+	return c.output.print(cc, restricted, c.distr, nil)
+}
+
+// extend adds the dependents and dependencies of apps, if requested.
+func (c *affectedCmd) extend(composition *deps.Composition, apps []string) ([]string, error) {
+	if c.withDependents {
+		var err error
+		apps, err = composition.WithDependents(c.distr, apps)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.withDependencies {
+		return composition.WithDependencies(c.distr, apps)
+	}
+
+	return apps, nil
+}
+
+func (c *affectedCmd) readChangedFiles(cc *cobra.Command) ([]string, error) {
+	var r io.Reader
+	if c.changedFiles == "" || c.changedFiles == "-" {
+		r = cc.InOrStdin()
+	} else {
+		f, err := os.Open(c.changedFiles)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var res []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		res = append(res, line)
+	}
+
+	return res, sc.Err()
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffected(t *testing.T) {
+	changedFiles := strings.Join([]string{
+		filepath.Join("c-service", "main.go"),
+		"README.md",
+	}, "\n")
+
+	t.Run("owners_only", func(t *testing.T) {
+		stdoutBuf := bytes.Buffer{}
+		cmd := newRoot()
+		cmd.SetArgs([]string{"affected", "--cfg-name", "deps.yaml", relTestDataDirPath, "prd"})
+		cmd.SetIn(strings.NewReader(changedFiles))
+		cmd.SetOut(&stdoutBuf)
+		require.NoError(t, cmd.Execute())
+
+		require.Equal(t, "c-service\n", stdoutBuf.String())
+	})
+
+	t.Run("with_dependents", func(t *testing.T) {
+		stdoutBuf := bytes.Buffer{}
+		cmd := newRoot()
+		cmd.SetArgs([]string{"affected", "--cfg-name", "deps.yaml", "--with-dependents", relTestDataDirPath, "prd", "-"})
+		cmd.SetIn(strings.NewReader(changedFiles))
+		cmd.SetOut(&stdoutBuf)
+		require.NoError(t, cmd.Execute())
+
+		require.Equal(t, "b-service\nc-service\na-service\n", stdoutBuf.String())
+	})
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/runner"
+)
+
+const applyShortHelp = "Execute a deployment plan and record the progress in a state file."
+
+var applyLongHelp = applyShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  PLAN-FILE	- Plan created by the plan command.
+  CMD-TEMPLATE	- Command that is executed for each app, followed by its
+		  arguments. Each element is a Go text/template.
+		  The fields {{.App}}, {{.Distribution}} and {{.Wave}} are
+		  available.
+
+The command is executed for the apps of the plan like by the run command.
+The result of each app is recorded in the state file, by default
+<PLAN-FILE>.state. When apply is run again with the same state file, apps that
+already succeeded are not executed again. This allows to resume an interrupted
+or partially failed rollout. A state file can only be used with the plan it
+was created for, apply fails if the plan changed.
+
+The dependency definitions are read from ROOT-DIR or DEP-TREE-FILE. If they
+changed since the plan was created, apply fails without executing any command.
+
+`+descrDependencyFileNames+`
+
+Exit Codes:
+`+fmt.Sprintf("  %d\t- The commands of all apps succeeded.\n", ExitCodeSuccess)+
+	fmt.Sprintf("  %d\t- An error occurred or the command of an app failed or was skipped.", ExitCodeError))
+
+type applyCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	statePath string
+	opts      runOptions
+
+	src      string
+	srcType  fs.PathType
+	planPath string
+}
+
+func newApplyCmd(root *rootCmd) *applyCmd {
+	cmd := applyCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "apply ROOT-DIR|DEP-TREE-FILE PLAN-FILE -- CMD-TEMPLATE...",
+			Short: applyShortHelp,
+			Long:  applyLongHelp,
+			Args:  cobra.MinimumNArgs(3),
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&cmd.statePath, "state", "",
+		"file in which the progress is recorded, defaults to <PLAN-FILE>.state",
+	)
+	cmd.opts.addFlags(cmd.Command)
+
+	cmd.PreRunE = func(cc *cobra.Command, args []string) error {
+		if err := cmd.opts.validate(cc, args, 2); err != nil {
+			return err
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.planPath = args[1]
+		if cmd.statePath == "" {
+			cmd.statePath = cmd.planPath + ".state"
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *applyCmd) run(cc *cobra.Command, _ []string) error {
+	plan, err := deps.PlanFromFile(c.planPath)
+	if err != nil {
+		return fmt.Errorf("loading plan failed: %w", err)
+	}
+
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	if err := composition.VerifyPlan(plan); err != nil {
+		return err
+	}
+
+	planHash, err := plan.Hash()
+	if err != nil {
+		return err
+	}
+
+	state, err := runner.StateFromFile(c.statePath, planHash)
+	if err != nil {
+		return fmt.Errorf("loading state failed: %w", err)
+	}
+
+	var stateErr error
+	onResult := func(res *runner.Result) {
+		state.Record(res)
+		if err := state.ToFile(c.statePath); err != nil && stateErr == nil {
+			stateErr = fmt.Errorf("writing state file failed: %w", err)
+		}
+	}
+
+	summary, err := c.opts.execute(cc, composition, plan.Distribution, plan.Waves, state.Completed(), onResult)
+	if err != nil {
+		return err
+	}
+
+	return errors.Join(stateErr, c.opts.finish(cc, summary))
+}
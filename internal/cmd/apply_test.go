@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyResumesAndRefusesChangedComposition(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+`)
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	cmd := newRoot()
+	cmd.SetArgs([]string{"plan", "--cfg-name", "deps.yaml", dir, "prd", planPath})
+	cmd.SetOut(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+
+	marker := filepath.Join(t.TempDir(), "executed")
+	apply := func() error {
+		cmd := newRoot()
+		cmd.SetArgs([]string{
+			"apply", "--cfg-name", "deps.yaml", dir, planPath, "--",
+			"sh", "-c", "echo {{.App}} >> " + marker + " && test {{.App}} != a -o -e " + marker + ".ok",
+		})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	// a fails, b succeeds
+	require.Error(t, apply())
+
+	// the second run only executes a
+	require.NoError(t, os.WriteFile(marker+".ok", nil, 0o644))
+	require.NoError(t, apply())
+
+	executed, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "b\na\na\n", string(executed))
+
+	writeDepsFile(t, dir, "c", `
+name: c
+dependencies:
+  prd:
+`)
+	err = apply()
+	require.ErrorContains(t, err, "composition changed")
+}
+
+func TestApplyRefusesStateOfOtherPlan(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+`)
+
+	planPath := filepath.Join(t.TempDir(), "plan.json")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	planAndApply := func(apps ...string) error {
+		require.NoError(t, os.RemoveAll(planPath))
+
+		args := []string{"plan", "--cfg-name", "deps.yaml", dir, "prd", planPath}
+		if len(apps) > 0 {
+			args = append(args, "--apps", strings.Join(apps, ","))
+		}
+		cmd := newRoot()
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		require.NoError(t, cmd.Execute())
+
+		cmd = newRoot()
+		cmd.SetArgs([]string{"apply", "--cfg-name", "deps.yaml", "--state", statePath, dir, planPath, "--", "true"})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		return cmd.Execute()
+	}
+
+	require.NoError(t, planAndApply("b"))
+	// the composition is unchanged but the plan contains other apps
+	require.ErrorContains(t, planAndApply(), "belongs to a different plan")
+}
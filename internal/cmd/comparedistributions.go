@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const compareDistributionsShortHelp = "Show the differences between two distributions."
+
+var compareDistributionsLongHelp = compareDistributionsShortHelp + "\n\n" + strings.TrimSpace(fmt.Sprintf(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTR-A	- Name of the first distribution.
+  DISTR-B	- Name of the second distribution.
+
+Apps that only exist in one of the distributions, dependencies that only exist
+in one of the distributions and dependencies whose type differs are reported.
+Dependencies from or to apps that only exist in one of the distributions are
+not reported separately.
+
+Known intentional differences can be listed in a YAML file that is passed via
+--allowlist. They are omitted from the report:
+
+  apps:
+    - debug-service
+  dependencies:
+    - from: billing-service
+      to: sandbox-payment-service
+
+An app in the allowlist may only exist in one of the distributions and its
+dependencies may differ.
+A dependency in the allowlist may only exist in one of the distributions or
+have different types.
+
+Exit Codes:
+ %d - Success, the distributions do not differ
+ %d - Error
+ %d - The distributions differ
+
+`, ExitCodeSuccess, ExitCodeError, ExitCodeIssuesFound)+descrDependencyFileNames)
+
+type compareDistributionsCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format    string
+	allowlist string
+
+	src     string
+	srcType fs.PathType
+	distrA  string
+	distrB  string
+}
+
+// distributionComparison is the JSON representation of the differences
+// between two distributions.
+type distributionComparison struct {
+	DistributionA  string                 `json:"distribution_a"`
+	DistributionB  string                 `json:"distribution_b"`
+	AppsOnlyInA    []string               `json:"apps_only_in_a"`
+	AppsOnlyInB    []string               `json:"apps_only_in_b"`
+	EdgesOnlyInA   []*deps.Edge           `json:"dependencies_only_in_a"`
+	EdgesOnlyInB   []*deps.Edge           `json:"dependencies_only_in_b"`
+	DifferentTypes []*deps.EdgeTypeChange `json:"different_types"`
+	Allowed        int                    `json:"allowed_differences"`
+}
+
+func newCompareDistributionsCmd(root *rootCmd) *compareDistributionsCmd {
+	cmd := compareDistributionsCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "compare-distributions ROOT-DIR|DEP-TREE-FILE DISTR-A DISTR-B",
+			Short: compareDistributionsShortHelp,
+			Long:  compareDistributionsLongHelp,
+			Args:  cobra.ExactArgs(3),
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(diffFormats, ", ")),
+	)
+	cmd.Flags().StringVar(
+		&cmd.allowlist, "allowlist", "",
+		"path to a YAML file listing known intentional differences",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if err := validateDiffFormat(cmd.format); err != nil {
+			return err
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distrA = args[1]
+		cmd.distrB = args[2]
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *compareDistributionsCmd) run(cc *cobra.Command, _ []string) error {
+	var allowlist *deps.Allowlist
+	if c.allowlist != "" {
+		var err error
+		allowlist, err = deps.AllowlistFromFile(c.allowlist)
+		if err != nil {
+			return err
+		}
+	}
+
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	diff, allowed, err := composition.CompareDistributions(c.distrA, c.distrB, allowlist)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "text":
+		c.writeText(cc, diff, allowed)
+	case "json":
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		err := enc.Encode(&distributionComparison{
+			DistributionA:  c.distrA,
+			DistributionB:  c.distrB,
+			AppsOnlyInA:    diff.RemovedApps,
+			AppsOnlyInB:    diff.AddedApps,
+			EdgesOnlyInA:   diff.RemovedEdges,
+			EdgesOnlyInB:   diff.AddedEdges,
+			DifferentTypes: diff.ChangedEdges,
+			Allowed:        allowed,
+		})
+		if err != nil {
+			return err
+		}
+	case "markdown":
+		c.writeMarkdown(cc, diff, allowed)
+	}
+
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	// do not print the error, the differences have already been printed
+	// to stdout
+	c.SilenceErrors = true
+	return NewErrWithExitCode(nil, ExitCodeIssuesFound)
+}
+
+func (c *compareDistributionsCmd) writeText(cc *cobra.Command, diff *deps.DistributionDiff, allowed int) {
+	if diff.IsEmpty() {
+		cc.Printf("distributions %s and %s do not differ\n", c.distrA, c.distrB)
+	} else {
+		for _, app := range diff.RemovedApps {
+			cc.Printf("app %s only exists in %s\n", app, c.distrA)
+		}
+		for _, app := range diff.AddedApps {
+			cc.Printf("app %s only exists in %s\n", app, c.distrB)
+		}
+		for _, e := range diff.RemovedEdges {
+			cc.Printf("dependency %s only exists in %s\n", e, c.distrA)
+		}
+		for _, e := range diff.AddedEdges {
+			cc.Printf("dependency %s only exists in %s\n", e, c.distrB)
+		}
+		for _, e := range diff.ChangedEdges {
+			cc.Printf("dependency %s -> %s is %s in %s and %s in %s\n",
+				e.From, e.To, e.OldType, c.distrA, e.NewType, c.distrB)
+		}
+	}
+
+	if allowed > 0 {
+		cc.Printf("%d allowed differences are not shown\n", allowed)
+	}
+}
+
+func (c *compareDistributionsCmd) writeMarkdown(cc *cobra.Command, diff *deps.DistributionDiff, allowed int) {
+	cc.Printf("## Distributions `%s` and `%s`\n\n", c.distrA, c.distrB)
+
+	if diff.IsEmpty() {
+		cc.Println("No differences.")
+	}
+
+	if len(diff.AddedApps) > 0 || len(diff.RemovedApps) > 0 {
+		cc.Print("### Apps\n\n")
+		cc.Println("| App | Only in |")
+		cc.Println("| --- | --- |")
+		for _, app := range diff.RemovedApps {
+			cc.Printf("| `%s` | `%s` |\n", app, c.distrA)
+		}
+		for _, app := range diff.AddedApps {
+			cc.Printf("| `%s` | `%s` |\n", app, c.distrB)
+		}
+		cc.Println()
+	}
+
+	if len(diff.AddedEdges) > 0 || len(diff.RemovedEdges) > 0 || len(diff.ChangedEdges) > 0 {
+		cc.Print("### Dependencies\n\n")
+		cc.Printf("| From | To | `%s` | `%s` |\n", c.distrA, c.distrB)
+		cc.Println("| --- | --- | --- | --- |")
+		for _, e := range diff.RemovedEdges {
+			cc.Printf("| `%s` | `%s` | %s | |\n", e.From, e.To, e.Type)
+		}
+		for _, e := range diff.AddedEdges {
+			cc.Printf("| `%s` | `%s` | | %s |\n", e.From, e.To, e.Type)
+		}
+		for _, e := range diff.ChangedEdges {
+			cc.Printf("| `%s` | `%s` | %s | %s |\n", e.From, e.To, e.OldType, e.NewType)
+		}
+		cc.Println()
+	}
+
+	if allowed > 0 {
+		cc.Printf("%d allowed differences are not shown.\n", allowed)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareDistributions(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+    c: ~
+    d: ~
+  stg:
+    b: {type: soft}
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+  stg:
+`)
+	writeDepsFile(t, dir, "d", `
+name: d
+dependencies:
+  prd:
+  stg:
+`)
+	writeDepsFile(t, dir, "c", `
+name: c
+dependencies:
+  prd:
+`)
+
+	t.Run("differences", func(t *testing.T) {
+		stdoutBuf := bytes.Buffer{}
+		cmd := newRoot()
+		cmd.SetArgs([]string{"compare-distributions", "--cfg-name", "deps.yaml", dir, "prd", "stg"})
+		cmd.SetOut(&stdoutBuf)
+		err := cmd.Execute()
+
+		var exitErr *ErrWithExitCode
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, ExitCodeIssuesFound, exitErr.exitCode)
+
+		out := stdoutBuf.String()
+		assert.Contains(t, out, "app c only exists in prd")
+		assert.Contains(t, out, "dependency a -> d (hard, declared in a/deps.yaml) only exists in prd")
+		// the dependency is reported with the app
+		assert.NotContains(t, out, "a -> c")
+		assert.Contains(t, out, "dependency a -> b is hard in prd and soft in stg")
+	})
+
+	t.Run("allowlist", func(t *testing.T) {
+		allowlist := filepath.Join(t.TempDir(), "allowlist.yaml")
+		require.NoError(t, os.WriteFile(allowlist, []byte(`
+apps: [c]
+dependencies:
+  - {from: a, to: b}
+  - {from: a, to: d}
+`), 0o644))
+
+		stdoutBuf := bytes.Buffer{}
+		cmd := newRoot()
+		cmd.SetArgs([]string{"compare-distributions", "--cfg-name", "deps.yaml", "--allowlist", allowlist, dir, "prd", "stg"})
+		cmd.SetOut(&stdoutBuf)
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "distributions prd and stg do not differ\n3 allowed differences are not shown\n", stdoutBuf.String())
+	})
+
+	t.Run("allowlisted_app_dependencies", func(t *testing.T) {
+		allowlist := filepath.Join(t.TempDir(), "allowlist.yaml")
+		require.NoError(t, os.WriteFile(allowlist, []byte(`
+apps: [a, c]
+`), 0o644))
+
+		stdoutBuf := bytes.Buffer{}
+		cmd := newRoot()
+		cmd.SetArgs([]string{"compare-distributions", "--cfg-name", "deps.yaml", "--allowlist", allowlist, dir, "prd", "stg"})
+		cmd.SetOut(&stdoutBuf)
+		require.NoError(t, cmd.Execute())
+		assert.Equal(t, "distributions prd and stg do not differ\n3 allowed differences are not shown\n", stdoutBuf.String())
+	})
+}
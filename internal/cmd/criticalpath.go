@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+)
+
+const criticalPathShortHelp = "Calculate the critical path and the estimated rollout duration."
+
+var criticalPathLongHelp = criticalPathShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+
+The deployment duration of an app is read from the optional deploy_duration
+field of its dependency configuration file. Apps without a deploy_duration are
+assumed to take --default-duration.
+
+The critical path is the chain of hard dependencies with the longest total
+duration. Its duration is the wall-clock time of the rollout, when all apps
+can be deployed in parallel. For each app the earliest start time, relative to
+the begin of the rollout, is listed.
+When --workers is passed, the wall-clock time of the rollout when at most
+that many apps are deployed in parallel is estimated additionally.
+In the json format durations are encoded as strings like "5m30s".
+
+`+descrDependencyFileNames)
+
+type criticalPathCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format          string
+	apps            []string
+	workers         int
+	defaultDuration time.Duration
+
+	src     string
+	distr   string
+	srcType fs.PathType
+}
+
+func newCriticalPathCmd(root *rootCmd) *criticalPathCmd {
+	cmd := criticalPathCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "critical-path ROOT-DIR|DEP-TREE-FILE DISTRIBUTION",
+			Short: criticalPathShortHelp,
+			Long:  criticalPathLongHelp,
+			Args:  cobra.ExactArgs(2),
+		},
+	}
+
+	supportedFormats := []string{"text", "json"}
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(supportedFormats, ", ")),
+	)
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to calculate the critical path for,\n"+
+			"if unset it is calculated for all found apps.",
+	)
+	cmd.Flags().IntVar(
+		&cmd.workers, "workers", 0,
+		"estimate additionally the rollout duration when at most this many\n"+
+			"apps are deployed in parallel",
+	)
+	cmd.Flags().DurationVar(
+		&cmd.defaultDuration, "default-duration", 0,
+		"deployment duration of apps that do not define a deploy_duration",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(supportedFormats, cmd.format) {
+			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
+				strings.Join(supportedFormats, ", "))
+		}
+
+		if cmd.workers < 0 {
+			return fmt.Errorf("--workers must be 0 or greater, got: %d", cmd.workers)
+		}
+
+		if cmd.defaultDuration < 0 {
+			return fmt.Errorf("--default-duration must not be negative, got: %s", cmd.defaultDuration)
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *criticalPathCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	estimate, err := composition.EstimateRollout(c.distr, c.defaultDuration, c.workers, c.apps...)
+	if err != nil {
+		return err
+	}
+
+	if c.format == "json" {
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(estimate)
+	}
+
+	cc.Printf("critical path (%s):\n", estimate.Duration)
+	for _, app := range estimate.CriticalPath {
+		cc.Printf("  %s\n", app)
+	}
+
+	cc.Printf("\nestimated rollout duration with unlimited parallelism: %s\n", estimate.Duration)
+	if estimate.Workers > 0 {
+		cc.Printf("estimated rollout duration with %d workers: %s\n", estimate.Workers, estimate.WorkersDuration)
+	}
+
+	cc.Println("\nearliest start times:")
+	for _, app := range estimate.Apps {
+		marker := ""
+		if app.Critical {
+			marker = " (critical)"
+		}
+		cc.Printf("  %s: start %s, duration %s%s\n", app.Name, app.EarliestStart, app.Duration, marker)
+	}
+
+	return nil
+}
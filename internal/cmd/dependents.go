@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+)
+
+const dependentsShortHelp = "List the apps that depend on an app."
+
+var dependentsLongHelp = dependentsShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  APP		- Name of the app for which the dependents are listed.
+
+All apps that directly or transitively depend on APP are listed.
+A dependent is of the type hard, if it depends on APP via a path of only hard
+dependencies, otherwise it is of the type soft.
+The depth of a dependent is the length of the shortest dependency path to APP.
+
+`+descrDependencyFileNames)
+
+type dependentsCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format string
+	depth  int
+
+	src     string
+	distr   string
+	app     string
+	srcType fs.PathType
+}
+
+func newDependentsCmd(root *rootCmd) *dependentsCmd {
+	cmd := dependentsCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "dependents ROOT-DIR|DEP-TREE-FILE DISTRIBUTION APP",
+			Short: dependentsShortHelp,
+			Long:  dependentsLongHelp,
+			Args:  cobra.ExactArgs(3),
+		},
+	}
+
+	supportedFormats := []string{"text", "dot", "json"}
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(supportedFormats, ", ")),
+	)
+	cmd.Flags().IntVar(
+		&cmd.depth, "depth", 0,
+		"only list dependents with a dependency path of at most this length,\n"+
+			"0 lists all dependents",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(supportedFormats, cmd.format) {
+			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
+				strings.Join(supportedFormats, ", "))
+		}
+
+		if cmd.depth < 0 {
+			return fmt.Errorf("--depth must be 0 or greater, got: %d", cmd.depth)
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		cmd.app = args[2]
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *dependentsCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "text":
+		dependents, err := composition.Dependents(c.distr, c.app, c.depth)
+		if err != nil {
+			return err
+		}
+		for _, d := range dependents {
+			cc.Printf("%s (%s, depth %d)\n", d.Name, d.Type, d.Depth)
+		}
+	case "dot":
+		depsgraph, err := composition.DependentsDot(c.distr, c.app, c.depth)
+		if err != nil {
+			return err
+		}
+
+		cc.Print(depsgraph) // depsgraph already contains a newline at the end
+	case "json":
+		dependents, err := composition.Dependents(c.distr, c.app, c.depth)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(dependents)
+	}
+
+	return nil
+}
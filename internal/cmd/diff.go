@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const diffShortHelp = "Show the differences between two dependency trees."
+
+var diffLongHelp = diffShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+  OLD	- ROOT-DIR or DEP-TREE-FILE of the old dependency tree.
+  NEW	- ROOT-DIR or DEP-TREE-FILE of the new dependency tree.
+
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+
+For each distribution added and removed apps, added and removed hard and soft
+dependencies and dependencies whose type changed are reported.
+
+`+descrDependencyFileNames)
+
+// diffFormats are the output formats of differences between dependency trees.
+var diffFormats = []string{"text", "json", "markdown"}
+
+type diffCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format string
+
+	oldSrc     string
+	oldSrcType fs.PathType
+	newSrc     string
+	newSrcType fs.PathType
+}
+
+func newDiffCmd(root *rootCmd) *diffCmd {
+	cmd := diffCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "diff OLD NEW",
+			Short: diffShortHelp,
+			Long:  diffLongHelp,
+			Args:  cobra.ExactArgs(2),
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(diffFormats, ", ")),
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if err := validateDiffFormat(cmd.format); err != nil {
+			return err
+		}
+
+		var err error
+		cmd.oldSrc = args[0]
+		cmd.oldSrcType, err = fs.FileOrDir(cmd.oldSrc)
+		if err != nil {
+			return err
+		}
+
+		cmd.newSrc = args[1]
+		cmd.newSrcType, err = fs.FileOrDir(cmd.newSrc)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *diffCmd) run(cc *cobra.Command, _ []string) error {
+	oldComp, err := c.root.loadComposition(c.oldSrcType, c.oldSrc)
+	if err != nil {
+		return fmt.Errorf("loading %s failed: %w", c.oldSrc, err)
+	}
+
+	newComp, err := c.root.loadComposition(c.newSrcType, c.newSrc)
+	if err != nil {
+		return fmt.Errorf("loading %s failed: %w", c.newSrc, err)
+	}
+
+	diff := deps.Diff(oldComp, newComp)
+
+	switch c.format {
+	case "text":
+		if diff.IsEmpty() {
+			cc.Println("no differences")
+			return nil
+		}
+		for _, distr := range diff.AddedDistributions {
+			cc.Printf("+ distribution %s\n", distr)
+		}
+		for _, distr := range diff.RemovedDistributions {
+			cc.Printf("- distribution %s\n", distr)
+		}
+		writeDistributionDiffsText(cc.OutOrStdout(), diff.Distributions)
+	case "json":
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(diff)
+	case "markdown":
+		if diff.IsEmpty() {
+			cc.Println("No differences.")
+			return nil
+		}
+		if len(diff.AddedDistributions) > 0 || len(diff.RemovedDistributions) > 0 {
+			cc.Println("## Distributions")
+			cc.Println()
+			for _, distr := range diff.AddedDistributions {
+				cc.Printf("- added `%s`\n", distr)
+			}
+			for _, distr := range diff.RemovedDistributions {
+				cc.Printf("- removed `%s`\n", distr)
+			}
+			cc.Println()
+		}
+		writeDistributionDiffsMarkdown(cc.OutOrStdout(), diff.Distributions)
+	}
+
+	return nil
+}
+
+func validateDiffFormat(format string) error {
+	if !slices.Contains(diffFormats, format) {
+		return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", format,
+			strings.Join(diffFormats, ", "))
+	}
+	return nil
+}
+
+// writeDistributionDiffsText writes diffs in a human-readable format to w.
+// Added elements are prefixed with "+", removed ones with "-" and changed
+// ones with "~".
+func writeDistributionDiffsText(w io.Writer, diffs []*deps.DistributionDiff) {
+	for i, d := range diffs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "distribution %s:\n", d.Distribution)
+		for _, app := range d.AddedApps {
+			fmt.Fprintf(w, "  + app %s\n", app)
+		}
+		for _, app := range d.RemovedApps {
+			fmt.Fprintf(w, "  - app %s\n", app)
+		}
+		for _, e := range d.AddedEdges {
+			fmt.Fprintf(w, "  + %s\n", e)
+		}
+		for _, e := range d.RemovedEdges {
+			fmt.Fprintf(w, "  - %s\n", e)
+		}
+		for _, e := range d.ChangedEdges {
+			fmt.Fprintf(w, "  ~ %s -> %s changed from %s to %s\n", e.From, e.To, e.OldType, e.NewType)
+		}
+	}
+}
+
+// writeDistributionDiffsMarkdown writes diffs as markdown to w.
+func writeDistributionDiffsMarkdown(w io.Writer, diffs []*deps.DistributionDiff) {
+	for i, d := range diffs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## Distribution `%s`\n", d.Distribution)
+
+		if len(d.AddedApps) > 0 || len(d.RemovedApps) > 0 {
+			fmt.Fprint(w, "\n### Apps\n\n")
+			fmt.Fprintln(w, "| Change | App |")
+			fmt.Fprintln(w, "| --- | --- |")
+			for _, app := range d.AddedApps {
+				fmt.Fprintf(w, "| added | `%s` |\n", app)
+			}
+			for _, app := range d.RemovedApps {
+				fmt.Fprintf(w, "| removed | `%s` |\n", app)
+			}
+		}
+
+		if len(d.AddedEdges) > 0 || len(d.RemovedEdges) > 0 || len(d.ChangedEdges) > 0 {
+			fmt.Fprint(w, "\n### Dependencies\n\n")
+			fmt.Fprintln(w, "| Change | From | To | Type | Declared in |")
+			fmt.Fprintln(w, "| --- | --- | --- | --- | --- |")
+			for _, e := range d.AddedEdges {
+				fmt.Fprintf(w, "| added | `%s` | `%s` | %s | %s |\n", e.From, e.To, e.Type, e.SourceFile)
+			}
+			for _, e := range d.RemovedEdges {
+				fmt.Fprintf(w, "| removed | `%s` | `%s` | %s | %s |\n", e.From, e.To, e.Type, e.SourceFile)
+			}
+			for _, e := range d.ChangedEdges {
+				fmt.Fprintf(w, "| changed | `%s` | `%s` | %s → %s | %s |\n", e.From, e.To, e.OldType, e.NewType, e.SourceFile)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+// writeDSMText writes the matrix as text table. The rows and columns are
+// numbered, the rows are labeled with the app names. Blocks are enclosed by
+// lines.
+func writeDSMText(w io.Writer, dsm *deps.DSM) error {
+	n := len(dsm.Apps)
+	if n == 0 {
+		return nil
+	}
+
+	// boundaries contains the indexes before which a block line is drawn
+	boundaries := map[int]bool{}
+	for _, b := range dsm.Blocks {
+		boundaries[b.Start] = true
+		boundaries[b.End] = true
+	}
+
+	numWidth := len(strconv.Itoa(n))
+	labelWidth := 0
+	for _, app := range dsm.Apps {
+		labelWidth = max(labelWidth, len(app))
+	}
+	cellWidth := max(numWidth, 1) + 1
+
+	var sb strings.Builder
+	row := func(label string, cell func(j int) string) {
+		sb.WriteString(label)
+		for j := range n {
+			if boundaries[j] {
+				sb.WriteString(" |")
+			}
+			fmt.Fprintf(&sb, "%*s", cellWidth, cell(j))
+		}
+		if boundaries[n] {
+			sb.WriteString(" |")
+		}
+		sb.WriteString("\n")
+	}
+	separator := func() {
+		row(strings.Repeat("-", numWidth+labelWidth+1), func(int) string {
+			return strings.Repeat("-", cellWidth)
+		})
+	}
+
+	row(strings.Repeat(" ", numWidth+labelWidth+1), func(j int) string {
+		return strconv.Itoa(j + 1)
+	})
+
+	for i, app := range dsm.Apps {
+		if boundaries[i] {
+			separator()
+		}
+		row(fmt.Sprintf("%*d %-*s", numWidth, i+1, labelWidth, app), func(j int) string {
+			if i == j {
+				return "-"
+			}
+			if dsm.Cells[i][j] == "" {
+				return "."
+			}
+			return dsm.Cells[i][j]
+		})
+	}
+	if boundaries[n] {
+		separator()
+	}
+
+	if len(dsm.Blocks) > 0 {
+		sb.WriteString("\nhard dependency loops:\n")
+		for _, b := range dsm.Blocks {
+			fmt.Fprintf(&sb, "  %d-%d: %s\n", b.Start+1, b.End, strings.Join(dsm.Apps[b.Start:b.End], ", "))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeDSMCSV writes the matrix as CSV. The first row contains the column
+// names, the first column the app of each row and the second column the
+// number of the block the app belongs to, starting at 1, or is empty.
+func writeDSMCSV(w io.Writer, dsm *deps.DSM) error {
+	blocks := make([]string, len(dsm.Apps))
+	for i, b := range dsm.Blocks {
+		for j := b.Start; j < b.End; j++ {
+			blocks[j] = strconv.Itoa(i + 1)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"app", "block"}, dsm.Apps...)); err != nil {
+		return err
+	}
+
+	for i, app := range dsm.Apps {
+		if err := cw.Write(append([]string{app, blocks[i]}, dsm.Cells[i]...)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,7 +1,8 @@
 package cmd
 
 const (
-	ExitCodeSuccess  = 0
-	ExitCodeError    = 1
-	ExitCodeNotFound = 2
+	ExitCodeSuccess     = 0
+	ExitCodeError       = 1
+	ExitCodeNotFound    = 2
+	ExitCodeIssuesFound = 3
 )
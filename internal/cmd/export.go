@@ -50,7 +50,7 @@ func newExportCmd(root *rootCmd) *exportCmd {
 }
 
 func (c *exportCmd) run(cc *cobra.Command, _ []string) error {
-	cmp, err := deps.CompositionFromDir(c.root, c.rootCmd.cfgName, c.rootCmd.ignoredDirs)
+	cmp, err := deps.CompositionFromDir(c.root, c.rootCmd.cfgName, c.rootCmd.ignoredDirs, c.rootCmd.mergeDuplicates)
 	if err != nil {
 		return err
 	}
@@ -59,6 +59,10 @@ func (c *exportCmd) run(cc *cobra.Command, _ []string) error {
 		return fmt.Errorf("could not find any dependency information in %s", c.root)
 	}
 
+	if err := cmp.VerifyAcyclic(); err != nil {
+		return err
+	}
+
 	if c.destFile == "" {
 		if err := cmp.ToJSON(os.Stdout); err != nil {
 			return err
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/compose"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const exportComposeShortHelp = "Write the dependencies of a distribution to the depends_on entries of a docker-compose file."
+
+var exportComposeLongHelp = exportComposeShortHelp + "\n\n" + strings.TrimSpace(fmt.Sprintf(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  COMPOSE-FILE	- Path to the docker-compose file.
+
+The depends_on entries of all services of COMPOSE-FILE that are apps of the
+distribution are replaced with their dependencies and the file is rewritten.
+Services that are not apps of the distribution are not changed.
+Hard dependencies are written as depends_on entries. If a service has soft
+dependencies, the long syntax is used and soft dependencies are written with
+"required: false". Dependencies on apps that are not services of COMPOSE-FILE
+are skipped and reported on stderr.
+
+When --check is passed, COMPOSE-FILE is not changed. Instead the differences
+between the dependencies of the apps of the distribution and the depends_on
+entries of the services are reported, that would be changed without --check.
+Services that are not apps and dependencies on apps that are not services
+are reported on stderr, they are not counted as differences.
+
+Exit Codes:
+ %d - Success, with --check: no differences found
+ %d - Error
+ %d - Differences found, with --check
+
+`, ExitCodeSuccess, ExitCodeError, ExitCodeIssuesFound)+descrDependencyFileNames)
+
+type exportComposeCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	check  bool
+	output string
+
+	src         string
+	distr       string
+	srcType     fs.PathType
+	composeFile string
+}
+
+func newExportComposeCmd(root *rootCmd) *exportComposeCmd {
+	cmd := exportComposeCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "export-compose ROOT-DIR|DEP-TREE-FILE DISTRIBUTION COMPOSE-FILE",
+			Short: exportComposeShortHelp,
+			Long:  exportComposeLongHelp,
+			Args:  cobra.ExactArgs(3),
+		},
+	}
+
+	cmd.Flags().BoolVar(
+		&cmd.check, "check", false,
+		"report the differences between the distribution and COMPOSE-FILE\n"+
+			"instead of rewriting it",
+	)
+	cmd.Flags().StringVar(
+		&cmd.output, "output", "",
+		"write the compose file to this path instead of overwriting\n"+
+			"COMPOSE-FILE, \"-\" writes it to stdout",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		cmd.composeFile = args[2]
+
+		if cmd.check && cmd.output != "" {
+			return fmt.Errorf("--check and --output are mutually exclusive")
+		}
+
+		if cmd.output == "" {
+			cmd.output = cmd.composeFile
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *exportComposeCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	f, err := compose.FromFile(c.composeFile)
+	if err != nil {
+		return fmt.Errorf("loading compose file %q failed: %w", c.composeFile, err)
+	}
+
+	if c.check {
+		return c.runCheck(cc, f, composition)
+	}
+
+	skipped, err := f.Update(composition, c.distr)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range skipped {
+		cc.PrintErrf("skipped dependency %s, %s is not a service of the compose file\n", e, e.To)
+	}
+
+	if c.output == "-" {
+		return f.Encode(cc.OutOrStdout())
+	}
+
+	if err := f.ToFile(c.output); err != nil {
+		return err
+	}
+
+	cc.Printf("written compose file to %s\n", filepath.Clean(c.output))
+	return nil
+}
+
+func (c *exportComposeCmd) runCheck(cc *cobra.Command, f *compose.File, composition *deps.Composition) error {
+	drift, err := f.Drift(composition, c.distr)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range drift.SkippedServices {
+		cc.PrintErrf("skipped service %s, it is not an app of the distribution\n", svc)
+	}
+	for _, e := range drift.SkippedEdges {
+		cc.PrintErrf("skipped dependency %s, %s is not a service of the compose file\n", e, e.To)
+	}
+
+	if drift.IsEmpty() {
+		return nil
+	}
+
+	for _, e := range drift.AddedEdges {
+		cc.Printf("%s depends_on %s, the dependency is not defined\n", e.From, e.To)
+	}
+	for _, e := range drift.RemovedEdges {
+		cc.Printf("dependency %s is missing in the depends_on entries of %s\n", e, e.From)
+	}
+	for _, e := range drift.ChangedEdges {
+		cc.Printf("%s -> %s is a %s dependency, but a %s dependency in the compose file\n",
+			e.From, e.To, e.OldType, e.NewType)
+	}
+
+	// do not print the error, the differences have already been printed
+	// to stdout
+	c.SilenceErrors = true
+	return NewErrWithExitCode(nil, ExitCodeIssuesFound)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportComposeThenCheckReportsNoDifferences(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "web", `
+name: web
+dependencies:
+  dev:
+    api: ~
+    auth: ~
+`)
+	writeDepsFile(t, dir, "api", `
+name: api
+dependencies:
+  dev:
+    db: {type: soft}
+`)
+	writeDepsFile(t, dir, "db", `
+name: db
+dependencies:
+  dev:
+`)
+	writeDepsFile(t, dir, "auth", `
+name: auth
+dependencies:
+  dev:
+`)
+
+	composeFile := filepath.Join(t.TempDir(), "compose.yaml")
+	require.NoError(t, os.WriteFile(composeFile, []byte(`
+services:
+  web:
+    image: web
+  api:
+    image: api
+    depends_on: [redis]
+  db:
+    image: postgres
+  redis:
+    image: redis
+`), 0o644))
+
+	cmd := newRoot()
+	cmd.SetArgs([]string{"export-compose", "--cfg-name", "deps.yaml", dir, "dev", composeFile})
+	cmd.SetOut(&bytes.Buffer{})
+	stderr := bytes.Buffer{}
+	cmd.SetErr(&stderr)
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stderr.String(), "skipped dependency web -> auth")
+
+	cmd = newRoot()
+	cmd.SetArgs([]string{"export-compose", "--check", "--cfg-name", "deps.yaml", dir, "dev", composeFile})
+	stdout := bytes.Buffer{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&bytes.Buffer{})
+	require.NoError(t, cmd.Execute())
+	assert.Empty(t, stdout.String())
+}
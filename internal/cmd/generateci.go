@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/ci"
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+)
+
+const generateCIShortHelp = "Generate a CI pipeline that deploys the apps in dependency order."
+
+var generateCILongHelp = generateCIShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+
+A job is generated for every app, by rendering the file passed via
+--job-template as Go text/template. The result must be a YAML mapping, that
+defines the job. The ID of each job is generated from the --job-id template.
+The fields {{.App}}, {{.Distribution}} and {{.Wave}} are available in both
+templates, {{.Needs}} contains in the job template the IDs of the jobs of the
+hard dependencies of the app.
+
+Each job needs the jobs of the hard dependencies of its app. When the job
+template contains a needs key, they are appended to it. Soft dependencies do
+not constrain the order.
+
+For --platform github, a GitHub Actions workflow is generated, that contains
+the jobs in the "jobs" key. Job IDs are sanitized to only contain characters
+that are allowed by GitHub. By default the workflow is triggered via
+workflow_dispatch.
+For --platform gitlab, a GitLab CI pipeline is generated, for example to be
+used as child pipeline. The jobs are top-level keys. Job IDs must not be
+reserved GitLab keywords, like default, stages or variables, and must not
+start with a dot.
+When --base is passed, the jobs are added to the YAML document in the file
+instead.
+
+The pipeline is written to stdout.
+
+`+descrDependencyFileNames)
+
+type generateCICmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	platform        string
+	jobTemplatePath string
+	jobID           string
+	basePath        string
+	apps            []string
+
+	src     string
+	distr   string
+	srcType fs.PathType
+}
+
+func newGenerateCICmd(root *rootCmd) *generateCICmd {
+	cmd := generateCICmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "generate-ci ROOT-DIR|DEP-TREE-FILE DISTRIBUTION",
+			Short: generateCIShortHelp,
+			Long:  generateCILongHelp,
+			Args:  cobra.ExactArgs(2),
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&cmd.platform, "platform", ci.PlatformGitHub,
+		fmt.Sprintf("CI platform, supported values: %s", strings.Join(ci.Platforms, ", ")),
+	)
+	cmd.Flags().StringVar(
+		&cmd.jobTemplatePath, "job-template", "",
+		"file containing the template of the job of an app (required)",
+	)
+	cmd.Flags().StringVar(
+		&cmd.jobID, "job-id", "deploy-{{.App}}",
+		"template of the job IDs",
+	)
+	cmd.Flags().StringVar(
+		&cmd.basePath, "base", "",
+		"YAML file to which the jobs are added",
+	)
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to generate jobs for, their dependencies\n"+
+			"are included, if unset jobs are generated for all found apps.",
+	)
+	_ = cmd.MarkFlagRequired("job-template")
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(ci.Platforms, cmd.platform) {
+			return fmt.Errorf("unsupported --platform value: %q, expecting one of: %s ", cmd.platform,
+				strings.Join(ci.Platforms, ", "))
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *generateCICmd) run(cc *cobra.Command, _ []string) error {
+	gen, err := c.generator()
+	if err != nil {
+		return err
+	}
+
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	doc, err := gen.Generate(composition, c.distr, c.apps...)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(cc.OutOrStdout())
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func (c *generateCICmd) generator() (*ci.Generator, error) {
+	tmplContent, err := os.ReadFile(c.jobTemplatePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading job template failed: %w", err)
+	}
+
+	jobTmpl, err := template.New("job").Option("missingkey=error").Parse(string(tmplContent))
+	if err != nil {
+		return nil, fmt.Errorf("parsing job template failed: %w", err)
+	}
+
+	jobIDTmpl, err := template.New("job-id").Option("missingkey=error").Parse(c.jobID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --job-id template failed: %w", err)
+	}
+
+	gen := ci.Generator{
+		Platform:    c.platform,
+		JobTemplate: jobTmpl,
+		JobID:       jobIDTmpl,
+	}
+
+	if c.basePath != "" {
+		content, err := os.ReadFile(c.basePath)
+		if err != nil {
+			return nil, fmt.Errorf("reading base file failed: %w", err)
+		}
+
+		var base yaml.Node
+		if err := yaml.Unmarshal(content, &base); err != nil {
+			return nil, fmt.Errorf("parsing base file failed: %w", err)
+		}
+		gen.Base = &base
+	}
+
+	return &gen, nil
+}
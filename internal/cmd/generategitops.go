@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	internalfs "github.com/simplesurance/dependencies-tool/v3/internal/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/gitops"
+)
+
+const generateGitOpsShortHelp = "Generate Argo CD sync waves or Flux dependencies."
+
+var generateGitOpsLongHelp = generateGitOpsShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+
+For --tool argocd, the sync wave of every app is computed from the deployment
+waves, the first wave is 0. Soft dependencies do not constrain the order.
+For --tool flux, the dependsOn list of every app contains its hard
+dependencies.
+
+The json format writes a JSON object to stdout, that maps every app to its
+sync wave, respectively to the names of the apps it depends on.
+The patches format writes a patch for every app to the file
+<OUTPUT-DIR>/<APP>.yaml, existing files are overwritten. For Argo CD it is a
+patch of the Application, that sets the `+gitops.ArgoCDSyncWaveAnnotation+`
+annotation. For Flux it is a patch of the Kustomization, that sets
+spec.dependsOn. The names of the resources are generated from the --name
+template, the fields {{.App}} and {{.Distribution}} are available.
+
+`+descrDependencyFileNames)
+
+type generateGitOpsCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	tool      string
+	format    string
+	outputDir string
+	name      string
+	namespace string
+	apps      []string
+
+	src      string
+	distr    string
+	srcType  fs.PathType
+	nameTmpl *template.Template
+}
+
+func newGenerateGitOpsCmd(root *rootCmd) *generateGitOpsCmd {
+	cmd := generateGitOpsCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "generate-gitops ROOT-DIR|DEP-TREE-FILE DISTRIBUTION",
+			Short: generateGitOpsShortHelp,
+			Long:  generateGitOpsLongHelp,
+			Args:  cobra.ExactArgs(2),
+		},
+	}
+
+	supportedFormats := []string{"json", "patches"}
+	cmd.Flags().StringVar(
+		&cmd.tool, "tool", gitops.ToolArgoCD,
+		fmt.Sprintf("GitOps tool, supported values: %s", strings.Join(gitops.Tools, ", ")),
+	)
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "json",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(supportedFormats, ", ")),
+	)
+	cmd.Flags().StringVar(
+		&cmd.outputDir, "output-dir", "",
+		"directory to which the patches are written, required for --format patches",
+	)
+	cmd.Flags().StringVar(
+		&cmd.name, "name", "{{.App}}",
+		"template of the resource names in patches",
+	)
+	cmd.Flags().StringVar(
+		&cmd.namespace, "namespace", "",
+		"namespace of the resources in patches",
+	)
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to generate the output for, their\n"+
+			"dependencies are included, if unset it is generated for all found apps.",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(gitops.Tools, cmd.tool) {
+			return fmt.Errorf("unsupported --tool value: %q, expecting one of: %s ", cmd.tool,
+				strings.Join(gitops.Tools, ", "))
+		}
+
+		if !slices.Contains(supportedFormats, cmd.format) {
+			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
+				strings.Join(supportedFormats, ", "))
+		}
+
+		if cmd.format == "patches" && cmd.outputDir == "" {
+			return errors.New("--output-dir must be passed for --format patches")
+		}
+
+		var err error
+		cmd.nameTmpl, err = template.New("name").Option("missingkey=error").Parse(cmd.name)
+		if err != nil {
+			return fmt.Errorf("parsing --name template failed: %w", err)
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *generateGitOpsCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	if c.format == "json" {
+		mapping, err := c.mapping(composition)
+		if err != nil {
+			return err
+		}
+
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(mapping)
+	}
+
+	patches, err := c.patches(composition)
+	if err != nil {
+		return err
+	}
+
+	for app := range patches {
+		if !internalfs.IsFileName(app) {
+			return fmt.Errorf("app name %q can not be used as file name", app)
+		}
+	}
+
+	if err := os.MkdirAll(c.outputDir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory failed: %w", err)
+	}
+
+	for _, app := range slices.Sorted(maps.Keys(patches)) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(patches[app]); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(c.outputDir, app+".yaml"), buf.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	cc.Printf("written %d patches to %s\n", len(patches), filepath.Clean(c.outputDir))
+
+	return nil
+}
+
+func (c *generateGitOpsCmd) mapping(composition *deps.Composition) (any, error) {
+	if c.tool == gitops.ToolFlux {
+		return gitops.FluxDependsOn(composition, c.distr, c.apps...)
+	}
+	return gitops.ArgoCDSyncWaves(composition, c.distr, c.apps...)
+}
+
+func (c *generateGitOpsCmd) patches(composition *deps.Composition) (map[string]*gitops.Resource, error) {
+	gen := gitops.Generator{Name: c.nameTmpl, Namespace: c.namespace}
+
+	if c.tool == gitops.ToolFlux {
+		dependsOn, err := gitops.FluxDependsOn(composition, c.distr, c.apps...)
+		if err != nil {
+			return nil, err
+		}
+		return gen.FluxPatches(c.distr, dependsOn)
+	}
+
+	syncWaves, err := gitops.ArgoCDSyncWaves(composition, c.distr, c.apps...)
+	if err != nil {
+		return nil, err
+	}
+	return gen.ArgoCDPatches(c.distr, syncWaves)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/systemd"
+)
+
+const generateSystemdShortHelp = "Generate systemd drop-in files that order the units of the apps."
+
+var generateSystemdLongHelp = generateSystemdShortHelp + "\n\n" + strings.TrimSpace(fmt.Sprintf(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  OUTPUT-DIR	- Directory in which the drop-in directories are created,
+		  for example /etc/systemd/system.
+
+For every app the drop-in file <OUTPUT-DIR>/<UNIT>.d/`+systemd.DropInName+` is written,
+existing files are overwritten.
+Hard dependencies are converted to Requires= and After= entries, soft
+dependencies to Wants= entries.
+The unit names are generated from the --unit-pattern template, the fields
+{{.App}} and {{.Distribution}} are available.
+
+When --verify is passed, no files are written. Instead the generated drop-ins
+are compared with the files in OUTPUT-DIR. Missing and differing drop-ins are
+reported, and, if --apps is not passed, generated `+systemd.DropInName+` files of
+units for which no drop-in is generated anymore. `+systemd.DropInName+` files that
+were not generated by dependencies-tool are ignored.
+
+Exit Codes:
+ %d - Success, with --verify: no differences found
+ %d - Error
+ %d - Differences found, with --verify
+
+`, ExitCodeSuccess, ExitCodeError, ExitCodeIssuesFound)+descrDependencyFileNames)
+
+type generateSystemdCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	unitPattern string
+	verify      bool
+	apps        []string
+
+	src       string
+	distr     string
+	srcType   fs.PathType
+	outputDir string
+	unitTmpl  *template.Template
+}
+
+func newGenerateSystemdCmd(root *rootCmd) *generateSystemdCmd {
+	cmd := generateSystemdCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "generate-systemd ROOT-DIR|DEP-TREE-FILE DISTRIBUTION OUTPUT-DIR",
+			Short: generateSystemdShortHelp,
+			Long:  generateSystemdLongHelp,
+			Args:  cobra.ExactArgs(3),
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&cmd.unitPattern, "unit-pattern", "{{.App}}.service",
+		"template of the unit names",
+	)
+	cmd.Flags().BoolVar(
+		&cmd.verify, "verify", false,
+		"compare the generated drop-ins with the files in OUTPUT-DIR instead\n"+
+			"of writing them",
+	)
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to generate drop-ins for, their\n"+
+			"dependencies are included, if unset they are generated for all found apps.",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		var err error
+		cmd.unitTmpl, err = template.New("unit").Option("missingkey=error").Parse(cmd.unitPattern)
+		if err != nil {
+			return fmt.Errorf("parsing --unit-pattern template failed: %w", err)
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		cmd.outputDir = args[2]
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *generateSystemdCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	dropIns, err := systemd.DropIns(composition, c.distr, c.unitTmpl, c.apps...)
+	if err != nil {
+		return err
+	}
+
+	if !c.verify {
+		if err := systemd.Write(c.outputDir, dropIns); err != nil {
+			return err
+		}
+
+		cc.Printf("written %d drop-ins to %s\n", len(dropIns), filepath.Clean(c.outputDir))
+		return nil
+	}
+
+	diffs, err := systemd.Verify(c.outputDir, dropIns)
+	if err != nil {
+		return err
+	}
+
+	found := 0
+	for _, d := range diffs {
+		// drop-ins of apps that were not selected are expected
+		if d.Unexpected && len(c.apps) > 0 {
+			continue
+		}
+		cc.Println(d)
+		found++
+	}
+
+	if found == 0 {
+		return nil
+	}
+
+	// do not print the error, the differences have already been printed
+	// to stdout
+	c.SilenceErrors = true
+	return NewErrWithExitCode(nil, ExitCodeIssuesFound)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/compose"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/fs"
+)
+
+const importShortHelp = "Create dependency definitions from other sources."
+
+const importComposeShortHelp = "Create dependency definitions from the depends_on entries of a docker-compose file."
+
+var importComposeLongHelp = importComposeShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+  COMPOSE-FILE	- Path to the docker-compose file.
+  DISTRIBUTION	- Name of the distribution the dependencies are defined for.
+  OUTPUT-DIR	- Directory in which the dependency definition files are
+		  created. If omitted, the dependencies are written as
+		  dependency tree to stdout, that can be used as DEP-TREE-FILE
+		  argument.
+
+Every service of the compose file is converted to an app. depends_on entries
+are converted to hard dependencies, entries with "required: false" to soft
+dependencies.
+
+For every service the file <OUTPUT-DIR>/<SERVICE>/<CFG-NAME> is written,
+CFG-NAME is the value of --cfg-name. If one of the files already exists, no
+files are written, unless --update is passed. With --update, the DISTRIBUTION
+entry of existing files is replaced and the other entries are kept. Comments
+and YAML anchors of updated files are not preserved.
+`)
+
+type importCmd struct {
+	root *rootCmd
+	*cobra.Command
+}
+
+func newImportCmd(root *rootCmd) *importCmd {
+	cmd := importCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "import SOURCE",
+			Short: importShortHelp,
+			Long:  importShortHelp,
+		},
+	}
+
+	cmd.AddCommand(newImportComposeCmd(root).Command)
+
+	return &cmd
+}
+
+type importComposeCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	update bool
+
+	composeFile string
+	distr       string
+	outputDir   string
+}
+
+func newImportComposeCmd(root *rootCmd) *importComposeCmd {
+	cmd := importComposeCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "compose COMPOSE-FILE DISTRIBUTION [OUTPUT-DIR]",
+			Short: importComposeShortHelp,
+			Long:  importComposeLongHelp,
+			Args:  cobra.RangeArgs(2, 3),
+		},
+	}
+
+	cmd.Flags().BoolVar(
+		&cmd.update, "update", false,
+		"replace the DISTRIBUTION entry of existing dependency definition files",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		cmd.composeFile = args[0]
+		cmd.distr = args[1]
+		if len(args) == 3 {
+			cmd.outputDir = args[2]
+		}
+
+		if strings.TrimSpace(cmd.distr) == "" {
+			return errors.New("DISTRIBUTION is empty or contains only whitespaces")
+		}
+
+		if cmd.update && cmd.outputDir == "" {
+			return errors.New("--update can only be passed with an OUTPUT-DIR argument")
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *importComposeCmd) run(cc *cobra.Command, _ []string) error {
+	f, err := compose.FromFile(c.composeFile)
+	if err != nil {
+		return fmt.Errorf("loading compose file %q failed: %w", c.composeFile, err)
+	}
+
+	services, err := f.Dependencies()
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.composeFile, err)
+	}
+
+	composition := deps.NewComposition()
+	for svc, d := range services {
+		composition.Add(c.distr, svc, d)
+	}
+	if err := composition.Verify(); err != nil {
+		return fmt.Errorf("%s: %w", c.composeFile, err)
+	}
+
+	if c.outputDir == "" {
+		return composition.ToJSON(cc.OutOrStdout())
+	}
+
+	configs, err := c.configs(services)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range slices.Sorted(maps.Keys(configs)) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		if err := configs[path].ToFile(path); err != nil {
+			return fmt.Errorf("writing %s failed: %w", path, err)
+		}
+	}
+
+	cc.Printf("written %d dependency definition files to %s\n", len(configs), filepath.Clean(c.outputDir))
+	return nil
+}
+
+// configs returns the dependency definitions of the services, indexed by
+// the path of the file they are written to. When --update is passed,
+// existing files are loaded and their DISTRIBUTION entry is replaced.
+func (c *importComposeCmd) configs(services map[string]*deps.Dependencies) (map[string]*cfg.Config, error) {
+	var existing []string
+	res := make(map[string]*cfg.Config, len(services))
+
+	for svc, d := range services {
+		if !fs.IsFileName(svc) {
+			return nil, fmt.Errorf("service name %q can not be used as directory name", svc)
+		}
+
+		path := filepath.Join(c.outputDir, svc, c.root.cfgName)
+		config, err := cfg.FromFile(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			config = &cfg.Config{AppName: svc}
+		case err != nil:
+			return nil, fmt.Errorf("loading config file %q failed: %w", path, err)
+		case !c.update:
+			existing = append(existing, path)
+			continue
+		case config.AppName != svc:
+			return nil, fmt.Errorf("%s: defines the app %q, expecting %q", path, config.AppName, svc)
+		}
+
+		if config.Dependencies == nil {
+			config.Dependencies = map[string]map[string]*cfg.Attributes{}
+		}
+		config.Dependencies[c.distr] = d.CfgDependencies()
+		res[path] = config
+	}
+
+	if len(existing) > 0 {
+		slices.Sort(existing)
+		return nil, fmt.Errorf("dependency definition files already exist, pass --update to replace their %q entry: %s",
+			c.distr, strings.Join(existing, ", "))
+	}
+
+	return res, nil
+}
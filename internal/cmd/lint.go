@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const lintShortHelp = "Find redundant hard dependencies."
+
+var lintLongHelp = lintShortHelp + "\n\n" + strings.TrimSpace(fmt.Sprintf(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Names of the distributions to lint, if omitted all
+		  distributions are linted.
+
+A hard dependency is redundant, if it is already implied by other hard
+dependencies. For example, if a depends on b and b depends on c, a hard
+dependency from a to c is redundant.
+The redundant dependencies are found by computing the transitive reduction of
+the hard dependency graph of each distribution.
+
+Exit Codes:
+ %d - Success, no redundant dependencies found
+ %d - Error
+ %d - Redundant dependencies found
+
+`, ExitCodeSuccess, ExitCodeError, ExitCodeIssuesFound)+descrDependencyFileNames)
+
+type lintCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format string
+
+	src     string
+	distrs  []string
+	srcType fs.PathType
+}
+
+// lintResult are the redundant dependencies of a distribution.
+type lintResult struct {
+	Distribution      string       `json:"distribution"`
+	RedundantHardDeps []*deps.Edge `json:"redundant_hard_dependencies"`
+}
+
+func newLintCmd(root *rootCmd) *lintCmd {
+	cmd := lintCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "lint ROOT-DIR|DEP-TREE-FILE [DISTRIBUTION]...",
+			Short: lintShortHelp,
+			Long:  lintLongHelp,
+			Args:  cobra.MinimumNArgs(1),
+		},
+	}
+
+	supportedFormats := []string{"text", "json"}
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(supportedFormats, ", ")),
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(supportedFormats, cmd.format) {
+			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
+				strings.Join(supportedFormats, ", "))
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distrs = args[1:]
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *lintCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	distrs := c.distrs
+	if len(distrs) == 0 {
+		for distr := range composition.Distribution {
+			distrs = append(distrs, distr)
+		}
+		slices.Sort(distrs)
+	}
+
+	results := make([]*lintResult, 0, len(distrs))
+	issues := 0
+	for _, distr := range distrs {
+		redundant, err := composition.RedundantHardDependencies(distr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", distr, err)
+		}
+		results = append(results, &lintResult{Distribution: distr, RedundantHardDeps: redundant})
+		issues += len(redundant)
+	}
+
+	if c.format == "json" {
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		for _, res := range results {
+			for _, e := range res.RedundantHardDeps {
+				cc.Printf("%s: redundant hard dependency %s\n", res.Distribution, e)
+			}
+		}
+	}
+
+	if issues == 0 {
+		return nil
+	}
+
+	// do not print the error, the findings have already been printed to
+	// stdout
+	c.SilenceErrors = true
+	return NewErrWithExitCode(nil, ExitCodeIssuesFound)
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
 )
 
 const orderShortHelp = "Generate a deployment order."
@@ -31,14 +32,70 @@ The command can use as input either a marshalled dependency-tree file (DEP-TREE-
 or read and parse YAML configuration files that are found in the child directories of
 ROOT-DIR to generate a dependency-tree.
 
+The waves and waves-json formats group the apps into numbered deployment waves.
+All apps of a wave can be deployed in parallel, after the apps of all previous
+waves have been deployed.
+
+When --teardown is passed, the order for stopping or removing the apps is
+generated instead. Apps are ordered before the apps they depend on. Like for
+deployments, soft dependencies do not constrain the order.
+When --apps is passed, the teardown order contains the apps and all apps that
+depend directly or transitively on them, because they must be removed first.
+The dependencies of the apps are not part of the teardown order, they can
+still be used by other apps.
+--teardown is supported by the text, json, waves, waves-json and json-graph
+formats.
+
+The json-graph format writes a versioned JSON document with the apps as nodes,
+including their deployment wave and configuration file, and the dependencies as
+edges with their type. With --teardown the waves are teardown waves.
+
+The mermaid format writes a Mermaid flowchart, that can be embedded in
+markdown documents. Hard dependencies are drawn as solid arrows, soft
+dependencies as dotted arrows.
+The plantuml format writes a PlantUML component diagram. The distribution
+name is only written as title of the diagram. Edges are labeled with the
+dependency type.
+The graphml format writes a GraphML document, for example for yEd or Gephi.
+The distribution is only stored in the "distribution" attribute of the graph,
+the dependency type in the "type" attribute of the edges.
+
+The svg format renders the graph as SVG image, without requiring Graphviz.
+The apps of each deployment wave are drawn in one row, the first wave at the
+top. Soft dependencies are drawn as dashed arrows. If the hard dependencies
+contain loops, the rows are derived from the longest dependency paths instead.
+
+The dsm and dsm-csv formats write a design structure matrix as text table or
+CSV. The rows and columns are the apps in dependency order, a cell is H if the
+app of the row has a hard dependency on the app of the column, S for a soft
+dependency. Apps that depend on each other via hard dependency loops are
+partitioned into blocks, which are enclosed by lines in the dsm format and
+numbered in the block column of the dsm-csv format.
+
+When --reduce is passed, hard dependencies that are already implied by other
+hard dependencies are omitted in the dot, mermaid, plantuml, graphml and svg
+graphs. --reduce is not supported by the other formats and requires that the
+hard dependencies contain no loops.
+
+When --group-soft-deps is passed, apps that depend on each other via soft
+dependency loops are grouped as one deployment unit. In the text format the
+apps of a unit are written on the same line, separated by spaces. In the json
+format each unit is an array of apps. In the dot format the apps of a unit are
+drawn in a cluster.
+
 `+descrDependencyFileNames)
 
+// reducedOrderFormats are the elements of orderFormats that support
+// omitting transitively implied hard dependencies.
+var reducedOrderFormats = []string{"dot", "mermaid", "plantuml", "graphml", "svg"}
+
 type orderCmd struct {
 	root *rootCmd
 	*cobra.Command
 
-	format string
+	output orderOutput
 	apps   []string
+	reduce bool
 
 	src     string
 	distr   string
@@ -56,22 +113,26 @@ func newOrderCmd(root *rootCmd) *orderCmd {
 		},
 	}
 
-	supportedFormats := []string{"text", "dot", "json"}
-	cmd.Flags().StringVar(
-		&cmd.format, "format", "text",
-		fmt.Sprintf("output format, supported values: %s",
-			strings.Join(supportedFormats, ", ")),
-	)
+	cmd.output.addFlags(cmd.Command)
 	cmd.Flags().StringSliceVar(
 		&cmd.apps, "apps", nil,
 		"comma-separated list of apps to generate the deploy order for,\n"+
 			"if unset the dependency order is generated for all found apps.",
 	)
+	cmd.Flags().BoolVar(
+		&cmd.reduce, "reduce", false,
+		"remove hard dependencies that are implied transitively by other\n"+
+			"hard dependencies from the graph output, supported formats: "+strings.Join(reducedOrderFormats, ", "),
+	)
 
 	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
-		if !slices.Contains(supportedFormats, cmd.format) {
-			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
-				strings.Join(supportedFormats, ", "))
+		if err := cmd.output.validate(); err != nil {
+			return err
+		}
+
+		if cmd.reduce && !slices.Contains(reducedOrderFormats, cmd.output.format) {
+			return fmt.Errorf("--reduce is not supported with --format %s, expecting one of: %s",
+				cmd.output.format, strings.Join(reducedOrderFormats, ", "))
 		}
 
 		pType, err := fs.FileOrDir(args[0])
@@ -96,31 +157,33 @@ func (c *orderCmd) run(cc *cobra.Command, _ []string) error {
 		return err
 	}
 
-	switch c.format {
-	case "text":
-		order, err := composition.DependencyOrder(c.distr, c.apps...)
+	apps := c.apps
+	if c.output.teardown && len(apps) > 0 {
+		// the dependents of the apps must be torn down before them,
+		// their dependencies are kept
+		apps, err = composition.WithDependents(c.distr, apps)
 		if err != nil {
 			return err
 		}
-		cc.Println(strings.Join(order, "\n"))
-	case "dot":
-		depsgraph, err := composition.DependencyOrderDot(c.distr, c.apps...)
+
+		composition, err = composition.Restrict(c.distr, apps)
 		if err != nil {
 			return err
 		}
+		apps = nil
+	}
 
-		cc.Print(depsgraph) // depsgraph already contains a newline at the end
-	case "json":
-		order, err := composition.DependencyOrder(c.distr, c.apps...)
+	if c.reduce {
+		composition, err = composition.TransitiveReduction(c.distr)
 		if err != nil {
+			if errors.Is(err, graphs.ErrNoDAG) {
+				return fmt.Errorf("--reduce requires an acyclic graph: %w", err)
+			}
 			return err
 		}
-		enc := json.NewEncoder(cc.OutOrStdout())
-		enc.SetIndent("", "    ")
-		return enc.Encode(order)
 	}
 
-	return nil
+	return c.output.print(cc, composition, c.distr, apps)
 }
 
 func validateAppsParam(apps []string) error {
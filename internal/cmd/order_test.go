@@ -3,7 +3,9 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -56,3 +58,251 @@ a-service
 `
 	require.Equal(t, expected, stdoutBuf.String())
 }
+
+func TestDeployOrderWavesFormat(t *testing.T) {
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--format", "waves-json", relTestDataDirPath, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	err := cmd.Execute()
+	require.NoError(t, err, "order cmd failed")
+
+	var res [][]string
+	err = json.Unmarshal(stdoutBuf.Bytes(), &res)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"b-service"}, {"a-service", "c-service"}}, res)
+}
+
+func TestDeployOrderMermaidFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a.b
+dependencies:
+  prd:
+    a-b: ~
+    end: {type: soft}
+`)
+	writeDepsFile(t, dir, "b", `
+name: a-b
+dependencies:
+  prd:
+`)
+	writeDepsFile(t, dir, "c", `
+name: end
+dependencies:
+  prd:
+`)
+
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--format", "mermaid", dir, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	const expected = `flowchart TD
+    n_a_b["a-b"]
+    n_a_b_2["a.b"]
+    n_end["end"]
+    n_a_b_2 --> n_a_b
+    n_a_b_2 -.-> n_end
+`
+	require.Equal(t, expected, stdoutBuf.String())
+}
+
+func TestDeployOrderPlantUMLFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a.b
+dependencies:
+  prd:
+    a-b: ~
+    end: {type: soft}
+`)
+	writeDepsFile(t, dir, "b", `
+name: a-b
+dependencies:
+  prd:
+`)
+	writeDepsFile(t, dir, "c", `
+name: end
+dependencies:
+  prd:
+`)
+
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--format", "plantuml", dir, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	const expected = `@startuml
+title prd
+component "a-b" as n_a_b
+component "a.b" as n_a_b_2
+component "end" as n_end
+n_a_b_2 --> n_a_b : hard
+n_a_b_2 ..> n_end : soft
+@enduml
+`
+	require.Equal(t, expected, stdoutBuf.String())
+}
+
+func TestDeployOrderGraphMLFormat(t *testing.T) {
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--format", "graphml", relTestDataDirPath, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	var doc struct {
+		Graph struct {
+			Data  []data `xml:"data"`
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   data   `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	require.NoError(t, xml.Unmarshal(stdoutBuf.Bytes(), &doc))
+
+	assert.Equal(t, []data{{Key: "g_distribution", Value: "prd"}}, doc.Graph.Data)
+	assert.Len(t, doc.Graph.Nodes, 3)
+
+	edges := map[string]string{}
+	for _, e := range doc.Graph.Edges {
+		edges[e.Source+" -> "+e.Target] = e.Data.Value
+	}
+	assert.Equal(t, map[string]string{
+		"a-service -> b-service": "hard",
+		"b-service -> c-service": "soft",
+		"c-service -> b-service": "hard",
+	}, edges)
+}
+
+func TestDeployOrderSVGFormat(t *testing.T) {
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--format", "svg", relTestDataDirPath, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Nodes   []struct {
+			Rect struct {
+				Y string `xml:"y,attr"`
+			} `xml:"rect"`
+			Text string `xml:"text"`
+		} `xml:"g"`
+	}
+	require.NoError(t, xml.Unmarshal(stdoutBuf.Bytes(), &doc))
+
+	rows := map[string]string{}
+	for _, n := range doc.Nodes {
+		if n.Text != "" {
+			rows[n.Text] = n.Rect.Y
+		}
+	}
+	require.Len(t, rows, 3)
+	// a-service and c-service are in the same wave
+	assert.Equal(t, rows["a-service"], rows["c-service"])
+	assert.NotEqual(t, rows["a-service"], rows["b-service"])
+
+	assert.Equal(t, 1, strings.Count(stdoutBuf.String(), "stroke-dasharray"))
+}
+
+func TestTeardownOrderOfAppsContainsDependents(t *testing.T) {
+	// a -> b -> c, d -> b
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+    c: ~
+`)
+	writeDepsFile(t, dir, "c", `
+name: c
+dependencies:
+  prd:
+`)
+	writeDepsFile(t, dir, "d", `
+name: d
+dependencies:
+  prd:
+    b: ~
+`)
+
+	stdoutBuf := bytes.Buffer{}
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--teardown", "--apps", "b", "--format", "waves-json", dir, "prd"})
+	cmd.SetOut(&stdoutBuf)
+	require.NoError(t, cmd.Execute())
+
+	var res [][]string
+	require.NoError(t, json.Unmarshal(stdoutBuf.Bytes(), &res))
+	assert.Equal(t, [][]string{{"a", "d"}, {"b"}}, res)
+}
+
+func TestTeardownIsRejectedForGraphFormats(t *testing.T) {
+	for _, format := range []string{"dot", "mermaid", "svg", "dsm"} {
+		t.Run(format, func(t *testing.T) {
+			cmd := newRoot()
+			cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--teardown", "--format", format, relTestDataDirPath, "prd"})
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			require.ErrorContains(t, err, "--teardown is not supported")
+		})
+	}
+}
+
+func TestReduceIsRejectedForNonGraphFormats(t *testing.T) {
+	for _, format := range []string{"text", "json", "waves", "json-graph", "dsm"} {
+		t.Run(format, func(t *testing.T) {
+			cmd := newRoot()
+			cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--reduce", "--format", format, relTestDataDirPath, "prd"})
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			err := cmd.Execute()
+			require.ErrorContains(t, err, "--reduce is not supported")
+		})
+	}
+}
+
+func TestReduceFailsOnLoops(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+    a: ~
+`)
+
+	cmd := newRoot()
+	cmd.SetArgs([]string{"order", "--cfg-name", "deps.yaml", "--reduce", "--format", "dot", dir, "prd"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	require.ErrorContains(t, err, "--reduce requires an acyclic graph")
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// orderFormats are the output formats of dependency orders.
+var orderFormats = []string{"text", "dot", "json", "waves", "waves-json", "mermaid", "plantuml", "graphml", "json-graph", "svg", "dsm", "dsm-csv"}
+
+// graphWriters returns for graph output formats, except dot, a new
+// graphs.GraphWriter for the distribution.
+var graphWriters = map[string]func(distr string) graphs.GraphWriter{
+	"mermaid": func(string) graphs.GraphWriter {
+		return graphs.NewMermaidFlowchart()
+	},
+	"plantuml": func(distr string) graphs.GraphWriter {
+		return graphs.NewPlantUML(distr, deps.GraphEdgeLabels)
+	},
+	"graphml": func(distr string) graphs.GraphWriter {
+		return graphs.NewGraphML(map[string]string{"distribution": distr}, deps.GraphEdgeLabels)
+	},
+}
+
+// groupedOrderFormats are the elements of orderFormats that support
+// grouping soft dependency clusters.
+var groupedOrderFormats = []string{"text", "dot", "json"}
+
+// teardownOrderFormats are the elements of orderFormats that support
+// teardown orders.
+var teardownOrderFormats = []string{"text", "json", "waves", "waves-json", "json-graph"}
+
+// orderOutput defines how dependency orders are written.
+type orderOutput struct {
+	format        string
+	teardown      bool
+	groupSoftDeps bool
+}
+
+// addFlags registers the command-line flags of the orderOutput fields.
+func (o *orderOutput) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&o.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(orderFormats, ", ")),
+	)
+	cmd.Flags().BoolVar(
+		&o.teardown, "teardown", false,
+		"generate the order for tearing down the apps, apps are ordered\n"+
+			"before the apps they depend on, supported formats: "+strings.Join(teardownOrderFormats, ", "),
+	)
+	cmd.Flags().BoolVar(
+		&o.groupSoftDeps, "group-soft-deps", false,
+		"group apps that depend on each other via soft dependency loops\n"+
+			"as one deployment unit, supported formats: "+strings.Join(groupedOrderFormats, ", "),
+	)
+}
+
+func (o *orderOutput) validate() error {
+	if !slices.Contains(orderFormats, o.format) {
+		return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", o.format,
+			strings.Join(orderFormats, ", "))
+	}
+
+	if o.teardown && !slices.Contains(teardownOrderFormats, o.format) {
+		return fmt.Errorf("--teardown is not supported with --format %s, expecting one of: %s",
+			o.format, strings.Join(teardownOrderFormats, ", "))
+	}
+
+	if o.groupSoftDeps && !slices.Contains(groupedOrderFormats, o.format) {
+		return fmt.Errorf("--group-soft-deps is not supported with --format %s, expecting one of: %s",
+			o.format, strings.Join(groupedOrderFormats, ", "))
+	}
+
+	return nil
+}
+
+// print writes the dependency order of the apps of the distribution to the
+// output of cc.
+func (o *orderOutput) print(cc *cobra.Command, composition *deps.Composition, distr string, apps []string) error {
+	if o.groupSoftDeps {
+		return o.printGrouped(cc, composition, distr, apps)
+	}
+
+	orderFn := composition.DependencyOrder
+	wavesFn := composition.DependencyWaves
+	graphDocFn := composition.GraphDocument
+	if o.teardown {
+		orderFn = composition.TeardownOrder
+		wavesFn = composition.TeardownWaves
+		graphDocFn = composition.TeardownGraphDocument
+	}
+
+	switch o.format {
+	case "text":
+		order, err := orderFn(distr, apps...)
+		if err != nil {
+			return err
+		}
+		if len(order) > 0 {
+			cc.Println(strings.Join(order, "\n"))
+		}
+	case "dot":
+		depsgraph, err := composition.DependencyOrderDot(distr, apps...)
+		if err != nil {
+			return err
+		}
+
+		cc.Print(depsgraph) // depsgraph already contains a newline at the end
+	case "mermaid", "plantuml", "graphml":
+		graph := graphWriters[o.format](distr)
+		if err := composition.DependencyGraph(graph, distr, apps...); err != nil {
+			return err
+		}
+
+		cc.Print(graph.String())
+	case "json":
+		order, err := orderFn(distr, apps...)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(order)
+	case "svg":
+		graph, err := svgGraph(composition, distr, apps)
+		if err != nil {
+			return err
+		}
+
+		cc.Print(graph.String())
+	case "dsm", "dsm-csv":
+		dsm, err := composition.DSM(distr, apps...)
+		if err != nil {
+			return err
+		}
+		if o.format == "dsm" {
+			return writeDSMText(cc.OutOrStdout(), dsm)
+		}
+		return writeDSMCSV(cc.OutOrStdout(), dsm)
+	case "json-graph":
+		doc, err := graphDocFn(distr, apps...)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(doc)
+	case "waves":
+		waves, err := wavesFn(distr, apps...)
+		if err != nil {
+			return err
+		}
+		for i, wave := range waves {
+			cc.Printf("wave %d:\n", i+1)
+			for _, app := range wave {
+				cc.Printf("  %s\n", app)
+			}
+		}
+	case "waves-json":
+		waves, err := wavesFn(distr, apps...)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(waves)
+	default:
+		panic(fmt.Sprintf("unsupported format: %q", o.format))
+	}
+
+	return nil
+}
+
+func (o *orderOutput) printGrouped(cc *cobra.Command, composition *deps.Composition, distr string, apps []string) error {
+	if o.format == "dot" {
+		depsgraph, err := composition.DependencyOrderDotGrouped(distr, apps...)
+		if err != nil {
+			return err
+		}
+
+		cc.Print(depsgraph) // depsgraph already contains a newline at the end
+		return nil
+	}
+
+	units, err := composition.DependencyOrderGrouped(distr, apps...)
+	if err != nil {
+		return err
+	}
+
+	if o.teardown {
+		// the reverse of a dependency order is a valid teardown order
+		slices.Reverse(units)
+		for _, unit := range units {
+			slices.Reverse(unit)
+		}
+	}
+
+	switch o.format {
+	case "text":
+		for _, unit := range units {
+			cc.Println(strings.Join(unit, " "))
+		}
+	case "json":
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(units)
+	default:
+		panic(fmt.Sprintf("unsupported format: %q", o.format))
+	}
+
+	return nil
+}
+
+// svgGraph returns the dependency graph as SVG with one layer per
+// deployment wave. If the hard dependencies contain loops, the layers are
+// derived from the edges.
+func svgGraph(composition *deps.Composition, distr string, apps []string) (*graphs.SVG, error) {
+	waves, err := composition.DependencyWaves(distr, apps...)
+	if err != nil {
+		var cycleErr *deps.CycleError
+		if !errors.As(err, &cycleErr) {
+			return nil, err
+		}
+
+		graph := graphs.NewSVG(distr, "")
+		return graph, composition.DependencyGraph(graph, distr, apps...)
+	}
+
+	graph := graphs.NewSVG(distr, "wave")
+	if err := composition.DependencyGraph(graph, distr, apps...); err != nil {
+		return nil, err
+	}
+
+	for i, wave := range waves {
+		for _, app := range wave {
+			graph.SetLayer(app, i)
+		}
+	}
+
+	return graph, nil
+}
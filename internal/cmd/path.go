@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const pathShortHelp = "Explain why an app depends on another app."
+
+var pathLongHelp = pathShortHelp + "\n\n" + strings.TrimSpace(fmt.Sprintf(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  FROM		- Name of the app from which the paths start.
+  TO		- Name of the app at which the paths end.
+
+All dependency paths from FROM to TO are listed, each edge of a path is shown
+with its dependency type and the file that declares it.
+In the dot format the dependency graph of FROM is generated, the paths are
+highlighted.
+
+Exit Codes:
+ %d - Success, a dependency path exists
+ %d - Error
+ %d - FROM does not depend on TO
+
+`, ExitCodeSuccess, ExitCodeError, ExitCodeNotFound)+descrDependencyFileNames)
+
+type pathCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	format   string
+	shortest bool
+	maxPaths int
+
+	src     string
+	distr   string
+	from    string
+	to      string
+	srcType fs.PathType
+}
+
+func newPathCmd(root *rootCmd) *pathCmd {
+	cmd := pathCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "path ROOT-DIR|DEP-TREE-FILE DISTRIBUTION FROM TO",
+			Short: pathShortHelp,
+			Long:  pathLongHelp,
+			Args:  cobra.ExactArgs(4),
+		},
+	}
+
+	supportedFormats := []string{"text", "dot", "json"}
+	cmd.Flags().StringVar(
+		&cmd.format, "format", "text",
+		fmt.Sprintf("output format, supported values: %s",
+			strings.Join(supportedFormats, ", ")),
+	)
+	cmd.Flags().BoolVar(
+		&cmd.shortest, "shortest", false,
+		"only list the paths with the lowest number of dependencies",
+	)
+	cmd.Flags().IntVar(
+		&cmd.maxPaths, "max-paths", 100,
+		"maximum number of paths that are listed, the shortest paths are\n"+
+			"listed first, 0 lists all paths",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		if !slices.Contains(supportedFormats, cmd.format) {
+			return fmt.Errorf("unsupported --format values: %q, expecting one of: %s ", cmd.format,
+				strings.Join(supportedFormats, ", "))
+		}
+
+		if cmd.maxPaths < 0 {
+			return fmt.Errorf("--max-paths must be 0 or greater, got: %d", cmd.maxPaths)
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		cmd.from = args[2]
+		cmd.to = args[3]
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *pathCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	paths, err := composition.DependencyPaths(c.distr, c.from, c.to, c.shortest, c.maxPaths)
+	if err != nil {
+		return err
+	}
+
+	switch c.format {
+	case "text":
+		for i, p := range paths {
+			if i > 0 {
+				cc.Println()
+			}
+			cc.Println(p)
+			for _, e := range p {
+				cc.Printf("  %s\n", e)
+			}
+		}
+	case "dot":
+		depsgraph, err := composition.DependencyPathsDot(c.distr, c.from, paths)
+		if err != nil {
+			return err
+		}
+
+		cc.Print(depsgraph) // depsgraph already contains a newline at the end
+	case "json":
+		if paths == nil {
+			paths = []deps.DependencyPath{}
+		}
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(paths); err != nil {
+			return err
+		}
+	}
+
+	if len(paths) > 0 {
+		return nil
+	}
+
+	if c.format == "text" {
+		cc.Printf("%q does not depend on %q\n", c.from, c.to)
+	}
+
+	// do not print the error, result message has already been printed to
+	// stdout
+	c.SilenceErrors = true
+	return NewErrWithExitCode(nil, ExitCodeNotFound)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const planShortHelp = "Create a deployment plan that can be executed with apply."
+
+var planLongHelp = planShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  PLAN-FILE	- File to which the plan is written, if omitted it is written
+		  to stdout.
+
+The plan is a JSON document that contains the distribution, the selected apps,
+the deployment waves and a hash of the dependency definitions it was created
+from. The hash does not change when only the order of dependencies, the
+locations of the configuration files or deploy durations change.
+
+`+descrDependencyFileNames)
+
+type planCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	apps []string
+
+	src      string
+	distr    string
+	srcType  fs.PathType
+	destFile string
+}
+
+func newPlanCmd(root *rootCmd) *planCmd {
+	cmd := planCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "plan ROOT-DIR|DEP-TREE-FILE DISTRIBUTION [PLAN-FILE]",
+			Short: planShortHelp,
+			Long:  planLongHelp,
+			Args:  cobra.RangeArgs(2, 3),
+		},
+	}
+
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to create the plan for, their dependencies\n"+
+			"are included, if unset the plan is created for all found apps.",
+	)
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		if len(args) == 3 {
+			cmd.destFile = args[2]
+		}
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *planCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	plan, err := composition.Plan(c.distr, c.apps...)
+	if err != nil {
+		return err
+	}
+
+	if c.destFile == "" {
+		return writePlan(cc.OutOrStdout(), plan)
+	}
+
+	f, err := os.OpenFile(c.destFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := writePlan(f, plan); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cc.Printf("written plan to %s\n", filepath.Clean(c.destFile))
+
+	return nil
+}
+
+func writePlan(w io.Writer, plan *deps.Plan) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(plan)
+}
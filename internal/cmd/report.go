@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/report"
+)
+
+const reportShortHelp = "Create an interactive HTML report of the dependencies of a distribution."
+
+var reportLongHelp = reportShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  OUTPUT-FILE	- File to which the report is written, if omitted it is
+		  written to stdout. An existing file is overwritten.
+
+The report is a single HTML file that can be viewed offline in a web browser,
+it does not load any external resources.
+It contains a searchable list of the apps with their dependencies and
+dependents, the deployment waves, hard dependency loops, redundant hard
+dependencies and soft dependency clusters, and an interactive graph.
+
+`+descrDependencyFileNames)
+
+type reportCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	src      string
+	distr    string
+	srcType  fs.PathType
+	destFile string
+}
+
+func newReportCmd(root *rootCmd) *reportCmd {
+	cmd := reportCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "report ROOT-DIR|DEP-TREE-FILE DISTRIBUTION [OUTPUT-FILE]",
+			Short: reportShortHelp,
+			Long:  reportLongHelp,
+			Args:  cobra.RangeArgs(2, 3),
+		},
+	}
+
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+		if len(args) == 3 {
+			cmd.destFile = args[2]
+		}
+
+		return nil
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *reportCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	r, err := report.New(composition, c.distr)
+	if err != nil {
+		return err
+	}
+
+	if c.destFile == "" {
+		return r.WriteHTML(cc.OutOrStdout())
+	}
+
+	f, err := os.Create(c.destFile)
+	if err != nil {
+		return err
+	}
+
+	if err := r.WriteHTML(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cc.Printf("written report to %s\n", filepath.Clean(c.destFile))
+
+	return nil
+}
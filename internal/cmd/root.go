@@ -27,8 +27,9 @@ var defaultExcludeDirs = []string{
 type rootCmd struct {
 	*cobra.Command
 
-	cfgName     string
-	ignoredDirs []string
+	cfgName         string
+	ignoredDirs     []string
+	mergeDuplicates bool
 }
 
 func newRoot() *rootCmd {
@@ -53,10 +54,31 @@ func newRoot() *rootCmd {
 		defaultExcludeDirs,
 		"comma-separated list of directory names that are excluded when searching for configuration files",
 	)
+	r.PersistentFlags().BoolVar(
+		&r.mergeDuplicates, "merge-duplicates", false,
+		"merge apps with the same name that are defined in multiple configuration files,\n"+
+			"each distribution of an app must be defined in only one of the files",
+	)
 
+	r.AddCommand(newAffectedCmd(&r).Command)
+	r.AddCommand(newApplyCmd(&r).Command)
+	r.AddCommand(newCompareDistributionsCmd(&r).Command)
 	r.AddCommand(newContainsCmd(&r).Command)
+	r.AddCommand(newCriticalPathCmd(&r).Command)
+	r.AddCommand(newDependentsCmd(&r).Command)
+	r.AddCommand(newDiffCmd(&r).Command)
 	r.AddCommand(newExportCmd(&r).Command)
+	r.AddCommand(newExportComposeCmd(&r).Command)
+	r.AddCommand(newGenerateCICmd(&r).Command)
+	r.AddCommand(newGenerateGitOpsCmd(&r).Command)
+	r.AddCommand(newGenerateSystemdCmd(&r).Command)
+	r.AddCommand(newImportCmd(&r).Command)
+	r.AddCommand(newLintCmd(&r).Command)
 	r.AddCommand(newOrderCmd(&r).Command)
+	r.AddCommand(newPathCmd(&r).Command)
+	r.AddCommand(newPlanCmd(&r).Command)
+	r.AddCommand(newReportCmd(&r).Command)
+	r.AddCommand(newRunCmd(&r).Command)
 	r.AddCommand(newVerify(&r).Command)
 
 	return &r
@@ -65,7 +87,7 @@ func newRoot() *rootCmd {
 func (r *rootCmd) loadComposition(srcType fs.PathType, src string) (*deps.Composition, error) {
 	switch srcType {
 	case fs.PathTypeDir:
-		return deps.CompositionFromDir(src, r.cfgName, r.ignoredDirs)
+		return deps.CompositionFromDir(src, r.cfgName, r.ignoredDirs, r.mergeDuplicates)
 
 	case fs.PathTypeFile:
 		return deps.CompositionFromJSON(src)
@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/runner"
+)
+
+const runShortHelp = "Execute a command for each app in dependency order."
+
+var runLongHelp = runShortHelp + "\n\n" + strings.TrimSpace(`
+Positional Arguments:
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+  DISTRIBUTION	- Name of the distribution.
+  CMD-TEMPLATE	- Command that is executed for each app, followed by its
+		  arguments. Each element is a Go text/template.
+		  The fields {{.App}}, {{.Distribution}} and {{.Wave}} are
+		  available.
+
+The apps are executed in the deployment waves of the "order --format waves"
+command. Up to --concurrency commands of a wave are executed in parallel, the
+next wave is started when all commands of the previous wave finished.
+When the command of an app fails, after --retries retries, the commands of all
+apps that depend directly or transitively via hard dependencies on it are
+skipped. The commands of independent apps are still executed.
+
+The output of the commands and progress messages are written to stderr, or
+when --log-dir is passed, the output of each app to the file
+<LOG-DIR>/<APP>.log.
+At the end a JSON summary of the results is written to stdout, or to the file
+specified by --summary.
+
+On SIGINT or SIGTERM running commands are killed and the remaining apps are
+skipped.
+
+`+descrDependencyFileNames+`
+
+Exit Codes:
+`+fmt.Sprintf("  %d\t- The commands of all apps succeeded.\n", ExitCodeSuccess)+
+	fmt.Sprintf("  %d\t- An error occurred or the command of an app failed or was skipped.", ExitCodeError))
+
+// runTemplateData are the fields that are available in the command template
+// of the run command.
+type runTemplateData struct {
+	App          string
+	Distribution string
+	Wave         int
+}
+
+type runCmd struct {
+	root *rootCmd
+	*cobra.Command
+
+	apps []string
+	opts runOptions
+
+	src     string
+	distr   string
+	srcType fs.PathType
+}
+
+func newRunCmd(root *rootCmd) *runCmd {
+	cmd := runCmd{
+		root: root,
+		Command: &cobra.Command{
+			Use:   "run ROOT-DIR|DEP-TREE-FILE DISTRIBUTION -- CMD-TEMPLATE...",
+			Short: runShortHelp,
+			Long:  runLongHelp,
+			Args:  cobra.MinimumNArgs(3),
+		},
+	}
+
+	cmd.Flags().StringSliceVar(
+		&cmd.apps, "apps", nil,
+		"comma-separated list of apps to execute the command for, their\n"+
+			"dependencies are included, if unset it is executed for all found apps.",
+	)
+	cmd.opts.addFlags(cmd.Command)
+
+	cmd.PreRunE = func(cc *cobra.Command, args []string) error {
+		if err := cmd.opts.validate(cc, args, 2); err != nil {
+			return err
+		}
+
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
+		cmd.src = args[0]
+		cmd.srcType = pType
+		cmd.distr = args[1]
+
+		return validateAppsParam(cmd.apps)
+	}
+	cmd.RunE = cmd.run
+
+	return &cmd
+}
+
+func (c *runCmd) run(cc *cobra.Command, _ []string) error {
+	composition, err := c.root.loadComposition(c.srcType, c.src)
+	if err != nil {
+		return err
+	}
+
+	waves, err := composition.DependencyWaves(c.distr, c.apps...)
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.opts.execute(cc, composition, c.distr, waves, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.opts.finish(cc, summary)
+}
+
+// runOptions are the flags and the command template of commands that
+// execute a command for each app.
+type runOptions struct {
+	concurrency int
+	timeout     time.Duration
+	retries     int
+	logDir      string
+	summaryPath string
+
+	templates []*template.Template
+}
+
+func (o *runOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(
+		&o.concurrency, "concurrency", 1,
+		"maximum number of commands that are executed in parallel",
+	)
+	cmd.Flags().DurationVar(
+		&o.timeout, "timeout", 0,
+		"maximum duration of a single command execution, 0 means no timeout",
+	)
+	cmd.Flags().IntVar(
+		&o.retries, "retries", 0,
+		"number of times a failed command is retried",
+	)
+	cmd.Flags().StringVar(
+		&o.logDir, "log-dir", "",
+		"directory to which the output of the command of each app is written",
+	)
+	cmd.Flags().StringVar(
+		&o.summaryPath, "summary", "",
+		"write the JSON summary to this file instead of stdout",
+	)
+}
+
+// validate validates the flags and parses the command template, which
+// follows the positionalArgs positional arguments after "--".
+func (o *runOptions) validate(cc *cobra.Command, args []string, positionalArgs int) error {
+	if cc.ArgsLenAtDash() != positionalArgs {
+		return errors.New("the command template must be separated from the positional arguments by \"--\"")
+	}
+
+	if o.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be 1 or greater, got: %d", o.concurrency)
+	}
+
+	if o.timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative, got: %s", o.timeout)
+	}
+
+	if o.retries < 0 {
+		return fmt.Errorf("--retries must be 0 or greater, got: %d", o.retries)
+	}
+
+	var err error
+	o.templates, err = parseCmdTemplate(args[positionalArgs:])
+	return err
+}
+
+func parseCmdTemplate(args []string) ([]*template.Template, error) {
+	res := make([]*template.Template, 0, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New(fmt.Sprintf("arg%d", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing command template argument %q failed: %w", arg, err)
+		}
+		res = append(res, tmpl)
+	}
+
+	return res, nil
+}
+
+// execute runs the command for the apps in waves.
+// Apps in completed are not executed again. onResult is passed to
+// runner.Runner.OnResult.
+func (o *runOptions) execute(
+	cc *cobra.Command,
+	composition *deps.Composition,
+	distr string,
+	waves [][]string,
+	completed map[string]struct{},
+	onResult func(*runner.Result),
+) (*runner.Summary, error) {
+	hardDeps, err := hardDepsOf(composition, distr, waves)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.logDir != "" {
+		if err := os.MkdirAll(o.logDir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating log directory failed: %w", err)
+		}
+	}
+
+	r := runner.Runner{
+		Concurrency: o.concurrency,
+		Timeout:     o.timeout,
+		Retries:     o.retries,
+		LogDir:      o.logDir,
+		Command: func(app string, wave int) ([]string, error) {
+			return o.command(distr, app, wave)
+		},
+		Output:    cc.ErrOrStderr(),
+		Log:       cc.ErrOrStderr(),
+		Completed: completed,
+		OnResult:  onResult,
+	}
+
+	ctx, stop := signal.NotifyContext(cc.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return r.Run(ctx, waves, hardDeps), nil
+}
+
+// finish writes the summary and returns an error if an app did not
+// succeed.
+func (o *runOptions) finish(cc *cobra.Command, summary *runner.Summary) error {
+	if err := o.writeSummary(cc, summary); err != nil {
+		return fmt.Errorf("writing summary failed: %w", err)
+	}
+
+	if err := summary.Err(); err != nil {
+		return NewErrWithExitCode(err, ExitCodeError)
+	}
+
+	return nil
+}
+
+// hardDepsOf returns the hard dependencies of the apps in waves.
+func hardDepsOf(composition *deps.Composition, distr string, waves [][]string) (map[string][]string, error) {
+	res := map[string][]string{}
+	for _, wave := range waves {
+		for _, app := range wave {
+			d, exists := composition.Distribution[distr][app]
+			if !exists {
+				return nil, fmt.Errorf("app %q not found in distribution %q", app, distr)
+			}
+			res[app] = d.HardDeps
+		}
+	}
+
+	return res, nil
+}
+
+func (o *runOptions) command(distr, app string, wave int) ([]string, error) {
+	data := runTemplateData{App: app, Distribution: distr, Wave: wave}
+
+	res := make([]string, 0, len(o.templates))
+	for _, tmpl := range o.templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &data); err != nil {
+			return nil, err
+		}
+		res = append(res, buf.String())
+	}
+
+	return res, nil
+}
+
+func (o *runOptions) writeSummary(cc *cobra.Command, summary *runner.Summary) error {
+	if o.summaryPath == "" {
+		enc := json.NewEncoder(cc.OutOrStdout())
+		enc.SetIndent("", "    ")
+		return enc.Encode(summary)
+	}
+
+	f, err := os.Create(o.summaryPath)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(summary); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
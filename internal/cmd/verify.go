@@ -3,7 +3,7 @@ package cmd
 import (
 	"strings"
 
-	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/cmd/fs"
 
 	"github.com/spf13/cobra"
 )
@@ -12,20 +12,28 @@ const verifyShortHelp = "Verify dependency files"
 
 var verifyLongHelp = verifyShortHelp + "\n\n" + strings.TrimSpace(`
 Positional Arguments:
-`+descRootDirArg,
+`+descRootDirArg+`
+  DEP-TREE-FILE	- Path to an exported dependency tree.
+
+The verification fails if an app depends on an app that is not defined for the
+same distribution or if the hard dependencies of any distribution form a loop.
+All distributions are checked, loops of all distributions are reported
+together.
+`,
 )
 
 type verify struct {
 	*cobra.Command
-	root *rootCmd
-	path string
+	root    *rootCmd
+	path    string
+	srcType fs.PathType
 }
 
 func newVerify(root *rootCmd) *verify {
 	cmd := verify{
 		root: root,
 		Command: &cobra.Command{
-			Use:   "verify PATH",
+			Use:   "verify ROOT-DIR|DEP-TREE-FILE",
 			Short: verifyShortHelp,
 			Long:  verifyLongHelp,
 			Args:  cobra.ExactArgs(1),
@@ -34,20 +42,32 @@ func newVerify(root *rootCmd) *verify {
 
 	cmd.RunE = cmd.run
 
-	cmd.PreRun = func(_ *cobra.Command, args []string) {
+	cmd.PreRunE = func(_ *cobra.Command, args []string) error {
+		pType, err := fs.FileOrDir(args[0])
+		if err != nil {
+			return err
+		}
+
 		cmd.path = args[0]
+		cmd.srcType = pType
+
+		return nil
 	}
 
 	return &cmd
 }
 
 func (c *verify) run(cc *cobra.Command, _ []string) error {
-	_, err := deps.CompositionFromDir(c.path, c.root.cfgName, c.root.ignoredDirs)
+	comp, err := c.root.loadComposition(c.srcType, c.path)
 	if err != nil {
 		return err
 	}
 
-	cc.Println("verification successful, no issues found")
+	if err := comp.VerifyAcyclic(); err != nil {
+		return err
+	}
+
+	cc.Printf("verification of %d distribution(s) successful, no issues found\n", len(comp.Distribution))
 
 	return nil
 }
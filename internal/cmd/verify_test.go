@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDepsFile(t *testing.T, dir, app, content string) {
+	t.Helper()
+
+	appDir := filepath.Join(dir, app)
+	require.NoError(t, os.MkdirAll(appDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "deps.yaml"), []byte(content), 0o644))
+}
+
+func TestVerifyReportsLoopsOfAllDistributions(t *testing.T) {
+	dir := t.TempDir()
+	writeDepsFile(t, dir, "a", `
+name: a
+dependencies:
+  prd:
+    b: ~
+  stg:
+    b: ~
+`)
+	writeDepsFile(t, dir, "b", `
+name: b
+dependencies:
+  prd:
+    a: ~
+  stg:
+    a: {type: soft}
+  testing:
+    b: ~
+`)
+
+	cmd := newRoot()
+	cmd.SetArgs([]string{"verify", "--cfg-name", "deps.yaml", dir})
+	err := cmd.Execute()
+	require.Error(t, err)
+	t.Log(err)
+
+	assert.Contains(t, err.Error(), `distribution "prd"`)
+	assert.Contains(t, err.Error(), `distribution "testing"`)
+	assert.NotContains(t, err.Error(), `distribution "stg"`)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+	assert.Contains(t, err.Error(), "b -> b")
+	assert.Contains(t, err.Error(), filepath.Join("b", "deps.yaml"))
+}
@@ -0,0 +1,367 @@
+// Package compose converts the depends_on entries of docker-compose files
+// from and to dependency definitions.
+//
+// A depends_on entry of a service is converted to a hard dependency. If the
+// entry has the attribute "required: false", it is converted to a soft
+// dependency.
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	"github.com/simplesurance/dependencies-tool/v3/internal/yamlnode"
+)
+
+// DefaultCondition is the condition of depends_on entries that are added in
+// the long syntax.
+const DefaultCondition = "service_started"
+
+// File is a docker-compose file. The YAML document is kept as node tree, to
+// preserve the formatting and comments of unchanged parts when it is
+// written.
+type File struct {
+	doc      yaml.Node
+	services *yaml.Node
+}
+
+// serviceDef contains the fields of a compose service that are evaluated.
+type serviceDef struct {
+	DependsOn dependsOn `yaml:"depends_on"`
+}
+
+// dependsOn is the depends_on field of a service, in the short or the long
+// syntax.
+type dependsOn map[string]*dependsOnAttributes
+
+type dependsOnAttributes struct {
+	Condition string `yaml:"condition"`
+	Required  *bool  `yaml:"required"`
+}
+
+func (d *dependsOn) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.SequenceNode {
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+
+		*d = make(dependsOn, len(names))
+		for _, name := range names {
+			(*d)[name] = &dependsOnAttributes{}
+		}
+
+		return nil
+	}
+
+	var m map[string]*dependsOnAttributes
+	if err := node.Decode(&m); err != nil {
+		return err
+	}
+	*d = m
+
+	return nil
+}
+
+// Unmarshal reads and decodes a docker-compose file from r.
+func Unmarshal(r io.Reader) (*File, error) {
+	var f File
+	if err := yaml.NewDecoder(r).Decode(&f.doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("compose file is empty")
+		}
+		return nil, err
+	}
+
+	if len(f.doc.Content) == 0 || f.doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("compose file does not contain a mapping")
+	}
+
+	f.services = yamlnode.Get(f.doc.Content[0], "services")
+	if f.services == nil || f.services.Kind != yaml.MappingNode {
+		return nil, errors.New("compose file does not contain a services mapping")
+	}
+
+	return &f, nil
+}
+
+// FromFile reads and decodes the docker-compose file at path.
+func FromFile(path string) (*File, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	return Unmarshal(fd)
+}
+
+// Services returns the names of the services, sorted.
+func (f *File) Services() []string {
+	var res []string
+	for i := 0; i+1 < len(f.services.Content); i += 2 {
+		if f.services.Content[i].Value == "<<" {
+			continue
+		}
+		res = append(res, f.services.Content[i].Value)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Dependencies returns the dependencies of all services, indexed by the
+// service name.
+func (f *File) Dependencies() (map[string]*deps.Dependencies, error) {
+	var services map[string]*serviceDef
+	if err := f.services.Decode(&services); err != nil {
+		return nil, fmt.Errorf("decoding services failed: %w", err)
+	}
+
+	res := make(map[string]*deps.Dependencies, len(services))
+	for name, svc := range services {
+		var d deps.Dependencies
+		if svc != nil {
+			for _, dep := range slices.Sorted(maps.Keys(svc.DependsOn)) {
+				attrs := svc.DependsOn[dep]
+				if attrs != nil && attrs.Required != nil && !*attrs.Required {
+					d.SoftDeps = append(d.SoftDeps, dep)
+					continue
+				}
+				d.HardDeps = append(d.HardDeps, dep)
+			}
+		}
+		res[name] = &d
+	}
+
+	return res, nil
+}
+
+// Update sets the depends_on entries of the services that are apps of the
+// distribution to their dependencies.
+// Services that are not part of the distribution are not changed.
+// Dependencies on apps that are not services of the file can not be
+// expressed, they are skipped and returned.
+func (f *File) Update(composition *deps.Composition, distribution string) ([]*deps.Edge, error) {
+	apps, exists := composition.Distribution[distribution]
+	if !exists {
+		return nil, fmt.Errorf("distribution %q does not exist", distribution)
+	}
+
+	expressed, skipped := expressible(apps, f.Services())
+	for _, svc := range slices.Sorted(maps.Keys(expressed)) {
+		d := expressed[svc]
+		if err := f.setDependsOn(svc, d.HardDeps, d.SoftDeps); err != nil {
+			return nil, err
+		}
+	}
+
+	return skipped, nil
+}
+
+// expressible returns the dependencies of the apps that are services, as
+// they can be written to depends_on entries, and the dependencies on apps
+// that are not services, that can not be written.
+func expressible(apps map[string]*deps.Dependencies, services []string) (map[string]*deps.Dependencies, []*deps.Edge) {
+	res := map[string]*deps.Dependencies{}
+	var skipped []*deps.Edge
+
+	for _, svc := range services {
+		d, exists := apps[svc]
+		if !exists {
+			continue
+		}
+
+		var expressed deps.Dependencies
+		for _, dep := range d.HardDeps {
+			if _, isSvc := slices.BinarySearch(services, dep); !isSvc {
+				skipped = append(skipped, &deps.Edge{From: svc, To: dep, Type: cfg.TypeHardDependency, SourceFile: d.SourceFile})
+				continue
+			}
+			expressed.HardDeps = append(expressed.HardDeps, dep)
+		}
+		for _, dep := range d.SoftDeps {
+			if _, isSvc := slices.BinarySearch(services, dep); !isSvc {
+				skipped = append(skipped, &deps.Edge{From: svc, To: dep, Type: cfg.TypeSoftDependency, SourceFile: d.SourceFile})
+				continue
+			}
+			expressed.SoftDeps = append(expressed.SoftDeps, dep)
+		}
+		res[svc] = &expressed
+	}
+
+	return res, skipped
+}
+
+// setDependsOn replaces the depends_on entry of the service svc.
+// The short syntax is used when the service has no soft dependencies and
+// depends_on does not already use the long syntax. Attributes of existing
+// entries in the long syntax are preserved.
+func (f *File) setDependsOn(svc string, hard, soft []string) error {
+	svcNode := yamlnode.Get(f.services, svc)
+	if svcNode == nil {
+		return fmt.Errorf("service %q does not exist", svc)
+	}
+	if svcNode.Kind == yaml.ScalarNode && svcNode.Tag == "!!null" {
+		svcNode.Kind = yaml.MappingNode
+		svcNode.Tag = ""
+		svcNode.Value = ""
+	}
+	if svcNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("service %q is not a mapping", svc)
+	}
+
+	if len(hard)+len(soft) == 0 {
+		yamlnode.Delete(svcNode, "depends_on")
+		return nil
+	}
+
+	cur := yamlnode.Get(svcNode, "depends_on")
+	if len(soft) == 0 && (cur == nil || cur.Kind != yaml.MappingNode) {
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, dep := range sortedNames(cur, hard) {
+			seq.Content = append(seq.Content, yamlnode.String(dep))
+		}
+		yamlnode.Set(svcNode, "depends_on", seq)
+		return nil
+	}
+
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, dep := range sortedNames(cur, append(slices.Clone(hard), soft...)) {
+		var attrs *yaml.Node
+		if cur != nil && cur.Kind == yaml.MappingNode {
+			attrs = yamlnode.Get(cur, dep)
+		}
+		if attrs == nil || attrs.Kind != yaml.MappingNode {
+			attrs = &yaml.Node{Kind: yaml.MappingNode}
+			yamlnode.Set(attrs, "condition", yamlnode.String(DefaultCondition))
+		}
+
+		if slices.Contains(soft, dep) {
+			yamlnode.Set(attrs, "required", yamlnode.Bool(false))
+		} else if req := yamlnode.Get(attrs, "required"); req != nil && req.Value != "true" {
+			yamlnode.Delete(attrs, "required")
+		}
+
+		m.Content = append(m.Content, yamlnode.String(dep), attrs)
+	}
+	yamlnode.Set(svcNode, "depends_on", m)
+
+	return nil
+}
+
+// Encode writes the YAML document to w.
+func (f *File) Encode(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&f.doc); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// ToFile writes the YAML document to the file at path, an existing file is
+// overwritten.
+func (f *File) ToFile(path string) error {
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Encode(fd); err != nil {
+		_ = fd.Close()
+		return err
+	}
+
+	return fd.Close()
+}
+
+// Drift are the differences between the apps of a distribution and the
+// services of a compose file, that Update would change.
+type Drift struct {
+	// Diff contains the differences between the dependencies of the
+	// apps that are services. The apps of the distribution are the old,
+	// the services the new state. Dependencies on apps that are not
+	// services are not compared.
+	*deps.DistributionDiff
+	// SkippedEdges are the dependencies on apps that are not services,
+	// they can not be expressed in the compose file.
+	SkippedEdges []*deps.Edge
+	// SkippedServices are the services that are not apps of the
+	// distribution.
+	SkippedServices []string
+}
+
+// Drift returns the differences between the apps of the distribution and
+// the services of f. Only the dependencies that can be expressed in f are
+// compared, after f was changed with Update, no differences are reported.
+func (f *File) Drift(composition *deps.Composition, distribution string) (*Drift, error) {
+	apps, exists := composition.Distribution[distribution]
+	if !exists {
+		return nil, fmt.Errorf("distribution %q does not exist", distribution)
+	}
+
+	services, err := f.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	expressed, skipped := expressible(apps, f.Services())
+
+	var skippedServices []string
+	for _, svc := range slices.Sorted(maps.Keys(services)) {
+		if _, exists := expressed[svc]; !exists {
+			skippedServices = append(skippedServices, svc)
+			delete(services, svc)
+		}
+	}
+
+	return &Drift{
+		DistributionDiff: deps.DiffApps(distribution, expressed, services),
+		SkippedEdges:     skipped,
+		SkippedServices:  skippedServices,
+	}, nil
+}
+
+// sortedNames returns names ordered like the entries of the depends_on node
+// cur, names that are not entries of cur are appended sorted.
+func sortedNames(cur *yaml.Node, names []string) []string {
+	var existing []string
+	if cur != nil {
+		switch cur.Kind {
+		case yaml.SequenceNode:
+			for _, n := range cur.Content {
+				existing = append(existing, n.Value)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(cur.Content); i += 2 {
+				existing = append(existing, cur.Content[i].Value)
+			}
+		}
+	}
+
+	res := make([]string, 0, len(names))
+	for _, n := range existing {
+		if slices.Contains(names, n) && !slices.Contains(res, n) {
+			res = append(res, n)
+		}
+	}
+
+	var added []string
+	for _, n := range names {
+		if !slices.Contains(res, n) {
+			added = append(added, n)
+		}
+	}
+	slices.Sort(added)
+
+	return append(res, added...)
+}
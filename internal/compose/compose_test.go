@@ -0,0 +1,119 @@
+package compose
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+const composeFile = `# local stack
+services:
+  web:
+    image: web
+    depends_on:
+      - api
+  api:
+    image: api # the api
+    depends_on:
+      db:
+        condition: service_healthy
+      redis:
+        condition: service_started
+        required: false
+  db:
+    image: postgres
+  redis:
+    image: redis
+`
+
+func TestDependencies(t *testing.T) {
+	f, err := Unmarshal(strings.NewReader(composeFile))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"api", "db", "redis", "web"}, f.Services())
+
+	services, err := f.Dependencies()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*deps.Dependencies{
+		"web":   {HardDeps: []string{"api"}},
+		"api":   {HardDeps: []string{"db"}, SoftDeps: []string{"redis"}},
+		"db":    {},
+		"redis": {},
+	}, services)
+}
+
+func TestUpdate(t *testing.T) {
+	f, err := Unmarshal(strings.NewReader(composeFile))
+	require.NoError(t, err)
+
+	comp := deps.NewComposition()
+	comp.Add("dev", "web", &deps.Dependencies{HardDeps: []string{"api", "auth"}})
+	comp.Add("dev", "api", &deps.Dependencies{HardDeps: []string{"db", "redis"}})
+	comp.Add("dev", "db", &deps.Dependencies{SoftDeps: []string{"redis"}})
+	comp.Add("dev", "redis", &deps.Dependencies{})
+	comp.Add("dev", "auth", &deps.Dependencies{})
+
+	skipped, err := f.Update(comp, "dev")
+	require.NoError(t, err)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, &deps.Edge{From: "web", To: "auth", Type: "hard"}, skipped[0])
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Encode(&buf))
+	assert.Equal(t, `# local stack
+services:
+  web:
+    image: web
+    depends_on:
+      - api
+  api:
+    image: api # the api
+    depends_on:
+      db:
+        condition: service_healthy
+      redis:
+        condition: service_started
+  db:
+    image: postgres
+    depends_on:
+      redis:
+        condition: service_started
+        required: false
+  redis:
+    image: redis
+`, buf.String())
+
+	// after the update the file does not differ from the distribution
+	drift, err := f.Drift(comp, "dev")
+	require.NoError(t, err)
+	assert.True(t, drift.IsEmpty())
+	assert.Equal(t, skipped, drift.SkippedEdges)
+	assert.Empty(t, drift.SkippedServices)
+}
+
+func TestDrift(t *testing.T) {
+	f, err := Unmarshal(strings.NewReader(composeFile))
+	require.NoError(t, err)
+
+	comp := deps.NewComposition()
+	comp.Add("dev", "web", &deps.Dependencies{HardDeps: []string{"api", "auth"}})
+	comp.Add("dev", "api", &deps.Dependencies{SoftDeps: []string{"db"}})
+	comp.Add("dev", "db", &deps.Dependencies{})
+	comp.Add("dev", "auth", &deps.Dependencies{})
+
+	drift, err := f.Drift(comp, "dev")
+	require.NoError(t, err)
+	assert.Empty(t, drift.AddedApps)
+	assert.Empty(t, drift.RemovedApps)
+	assert.Equal(t, []*deps.Edge{{From: "api", To: "redis", Type: "soft"}}, drift.AddedEdges)
+	assert.Empty(t, drift.RemovedEdges)
+	require.Len(t, drift.ChangedEdges, 1)
+	assert.Equal(t, &deps.EdgeTypeChange{From: "api", To: "db", OldType: "soft", NewType: "hard"}, drift.ChangedEdges[0])
+	assert.Equal(t, []*deps.Edge{{From: "web", To: "auth", Type: "hard"}}, drift.SkippedEdges)
+	assert.Equal(t, []string{"redis"}, drift.SkippedServices)
+}
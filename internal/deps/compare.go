@@ -0,0 +1,121 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
+)
+
+// Allowlist contains known intentional differences between two
+// distributions.
+type Allowlist struct {
+	// Apps are names of apps that may only exist in one of the
+	// distributions.
+	Apps []string `yaml:"apps"`
+	// Dependencies may only exist in one of the distributions or have
+	// different types.
+	Dependencies []*AllowedDependency `yaml:"dependencies"`
+}
+
+// AllowedDependency is a dependency between two apps, that may differ
+// between distributions.
+type AllowedDependency struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// AllowlistFromFile reads a YAML encoded Allowlist from the file at path.
+func AllowlistFromFile(path string) (*Allowlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res Allowlist
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&res); err != nil {
+		return nil, fmt.Errorf("decoding %s failed: %w", path, err)
+	}
+
+	return &res, nil
+}
+
+func (a *Allowlist) allowsApp(app string) bool {
+	return slices.Contains(a.Apps, app)
+}
+
+func (a *Allowlist) allowsDependency(from, to string) bool {
+	return slices.ContainsFunc(a.Dependencies, func(d *AllowedDependency) bool {
+		return d.From == from && d.To == to
+	})
+}
+
+// CompareDistributions returns the differences between the apps of the
+// distributions distrA and distrB.
+// Apps and dependencies that only exist in distrB are reported as added,
+// the ones that only exist in distrA as removed. The Distribution field of
+// the result is distrB.
+// Dependencies of apps that only exist in one of the distributions are not
+// reported, the apps are.
+// Differences that are listed in allowlist are omitted, their number is
+// returned as second value. Dependencies from or to an app in the allowlist
+// are also omitted. allowlist can be nil.
+// If one of the distributions does not exist an error is returned.
+func (c *Composition) CompareDistributions(distrA, distrB string, allowlist *Allowlist) (*DistributionDiff, int, error) {
+	for _, distr := range []string{distrA, distrB} {
+		if _, exists := c.Distribution[distr]; !exists {
+			return nil, 0, fmt.Errorf("distribution does not exist: %s", distr)
+		}
+	}
+
+	diff := DiffApps(distrB, c.Distribution[distrA], c.Distribution[distrB])
+
+	oneSided := datastructs.SliceToSet(slices.Concat(diff.AddedApps, diff.RemovedApps))
+	ofOneSidedApp := func(e *Edge) bool {
+		_, from := oneSided[e.From]
+		_, to := oneSided[e.To]
+		return from || to
+	}
+	diff.AddedEdges = slices.DeleteFunc(diff.AddedEdges, ofOneSidedApp)
+	diff.RemovedEdges = slices.DeleteFunc(diff.RemovedEdges, ofOneSidedApp)
+
+	if allowlist == nil {
+		return diff, 0, nil
+	}
+
+	allowed := 0
+	allowApp := func(app string) bool {
+		if allowlist.allowsApp(app) {
+			allowed++
+			return true
+		}
+		return false
+	}
+	allowEdge := func(from, to string) bool {
+		if allowlist.allowsApp(from) || allowlist.allowsApp(to) || allowlist.allowsDependency(from, to) {
+			allowed++
+			return true
+		}
+		return false
+	}
+
+	diff.AddedApps = slices.DeleteFunc(diff.AddedApps, allowApp)
+	diff.RemovedApps = slices.DeleteFunc(diff.RemovedApps, allowApp)
+	diff.AddedEdges = slices.DeleteFunc(diff.AddedEdges, func(e *Edge) bool {
+		return allowEdge(e.From, e.To)
+	})
+	diff.RemovedEdges = slices.DeleteFunc(diff.RemovedEdges, func(e *Edge) bool {
+		return allowEdge(e.From, e.To)
+	})
+	diff.ChangedEdges = slices.DeleteFunc(diff.ChangedEdges, func(e *EdgeTypeChange) bool {
+		return allowEdge(e.From, e.To)
+	})
+
+	return diff, allowed, nil
+}
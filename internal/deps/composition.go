@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 
 	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
 	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
@@ -33,8 +34,11 @@ func NewComposition() *Composition {
 // definitions that are found in rootdir or any of it's sub directories.
 // Compositions are load from files that match the relative path cfgPath.
 // Files that are in directories named as an element in ignoredDirs are ignored.
+// If multiple files define an app with the same name an error is returned.
+// When mergeDuplicates is true, the definitions are merged instead, as long
+// as each distribution of the app is only defined in one of the files.
 // CompositionFromDir calls *Composition.Verify() before it returns.
-func CompositionFromDir(rootdir string, cfgPath string, ignoredDirs []string) (*Composition, error) {
+func CompositionFromDir(rootdir string, cfgPath string, ignoredDirs []string, mergeDuplicates bool) (*Composition, error) {
 	realRoot, err := filepath.EvalSymlinks(rootdir)
 	if err != nil {
 		return nil, err
@@ -50,6 +54,7 @@ func CompositionFromDir(rootdir string, cfgPath string, ignoredDirs []string) (*
 	}
 
 	comp := NewComposition()
+	defs := newDefinitionTracker()
 	for _, p := range cfgPaths {
 		config, err := cfg.FromFile(p)
 		if err != nil {
@@ -60,15 +65,27 @@ func CompositionFromDir(rootdir string, cfgPath string, ignoredDirs []string) (*
 			return nil, fmt.Errorf("%s: %w", p, err)
 		}
 
+		relPath, err := filepath.Rel(realRoot, p)
+		if err != nil {
+			return nil, err
+		}
+
 		for distr, deps := range config.Dependencies {
 			app, err := dependenciesFromCfg(deps)
 			if err != nil {
 				return nil, fmt.Errorf("%q: %q: %w", p, distr, err)
 			}
+			app.SourceFile = relPath
+			app.DeployDuration = config.DeployDuration
+			defs.add(config.AppName, distr, relPath)
 			comp.Add(distr, config.AppName, app)
 		}
 	}
 
+	if err := defs.verify(mergeDuplicates); err != nil {
+		return nil, err
+	}
+
 	if err := comp.Verify(); err != nil {
 		return nil, err
 	}
@@ -206,31 +223,52 @@ func (c *Composition) createGraph(distribution string, apps []string) (*graphs.G
 	return g, nil
 }
 
-// DependencyOrder calculates the dependency order (reverse topological order)
-// for the given distribution and returns it as string slice.
-// The dependencies of an app, are ordered before the apps that depend on them.
-// If a loop exist between hard dependencies an error is returned.
-// If apps is not empty, the order is only calculated for the given app names
-// instead of all.
-// If an app name is not part of the distribution and error is returned.
-func (c *Composition) DependencyOrder(distribution string, apps ...string) ([]string, error) {
+// topologicalSort creates the dependency graph for the given distribution
+// and apps and sorts it topologically.
+// It returns the topological order and the topological classes of the apps.
+// The rootVertexName start vertex is not part of the result.
+func (c *Composition) topologicalSort(distribution string, apps []string) ([]string, map[string]int, error) {
 	g, err := c.createGraph(distribution, apps)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	sorted, _, err := graphs.TopologicalSort(g)
+	sorted, classes, err := graphs.TopologicalSort(g)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, graphs.ErrNoDAG) {
+			return nil, nil, c.newCycleError(distribution, g)
+		}
+		return nil, nil, err
 	}
 
 	order := datastructs.ListToSlice(sorted)
+	if len(order) == 0 {
+		// the graph contains no apps, only the root vertex without
+		// edges
+		return order, classes, nil
+	}
 
 	// remove the rootVertexName start vertex from the list:
 	if order[0] != rootVertexName {
 		panic(fmt.Sprintf("BUG: first element in the topological sort list is %s, expecting %s\n", order[0], rootVertexName))
 	}
-	order = order[1:]
+	delete(classes, rootVertexName)
+
+	return order[1:], classes, nil
+}
+
+// DependencyOrder calculates the dependency order (reverse topological order)
+// for the given distribution and returns it as string slice.
+// The dependencies of an app, are ordered before the apps that depend on them.
+// If a loop exist between hard dependencies a *CycleError is returned.
+// If apps is not empty, the order is only calculated for the given app names
+// instead of all.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) DependencyOrder(distribution string, apps ...string) ([]string, error) {
+	order, _, err := c.topologicalSort(distribution, apps)
+	if err != nil {
+		return nil, err
+	}
 
 	// in Topological order the parent vertex come first, we need the
 	// reverse order, dependencies/childs must be ordered before their
@@ -240,6 +278,110 @@ func (c *Composition) DependencyOrder(distribution string, apps ...string) ([]st
 	return (order), nil
 }
 
+// DependencyWaves groups the apps of the distribution into deployment waves.
+// All apps in a wave can be deployed in parallel, after all apps of the
+// previous waves have been deployed.
+// An app is placed in the wave after the last wave of its hard dependencies,
+// apps without hard dependencies are in the first wave. The first element of
+// the returned slice is the first wave.
+// The apps in a wave are sorted by name.
+// If a loop exist between hard dependencies an error is returned.
+// If apps is not empty, the waves are only calculated for the given app names
+// and their dependencies instead of all.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) DependencyWaves(distribution string, apps ...string) ([][]string, error) {
+	order, _, err := c.topologicalSort(distribution, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	distrDeps := c.Distribution[distribution]
+	waveOf := make(map[string]int, len(order))
+	var waves [][]string
+
+	// in the reverse topological order the dependencies of an app are
+	// ordered before it, their waves are known when the app is placed
+	for _, app := range slices.Backward(order) {
+		wave := 0
+		if deps, exists := distrDeps[app]; exists {
+			for _, hd := range deps.HardDeps {
+				wave = max(wave, waveOf[hd]+1)
+			}
+		}
+		waveOf[app] = wave
+
+		if wave == len(waves) {
+			waves = append(waves, nil)
+		}
+		waves[wave] = append(waves[wave], app)
+	}
+
+	for _, wave := range waves {
+		slices.Sort(wave)
+	}
+
+	return waves, nil
+}
+
+// TeardownOrder calculates the order in which the apps of the distribution
+// can be stopped or removed (topological order).
+// Apps are ordered before their dependencies, it is the reverse of the
+// constraints that DependencyOrder fulfills. Soft dependencies do not
+// constrain the order.
+// If a loop exist between hard dependencies a *CycleError is returned.
+// If apps is not empty, the order is only calculated for the given app names
+// and their dependencies instead of all.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) TeardownOrder(distribution string, apps ...string) ([]string, error) {
+	order, _, err := c.topologicalSort(distribution, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// TeardownWaves groups the apps of the distribution into teardown waves.
+// All apps in a wave can be stopped or removed in parallel, after all apps of
+// the previous waves have been removed.
+// Apps are placed in earlier waves than their dependencies.
+// The apps in a wave are sorted by name.
+// If a loop exist between hard dependencies an error is returned.
+// If apps is not empty, the waves are only calculated for the given app names
+// and their dependencies instead of all.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) TeardownWaves(distribution string, apps ...string) ([][]string, error) {
+	_, classes, err := c.topologicalSort(distribution, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	return wavesFromClasses(classes), nil
+}
+
+// wavesFromClasses groups the apps by their topological classes.
+// Class 0 is the root vertex, apps have classes >= 1.
+// The apps with the lowest class are in the first wave. They are the apps
+// that no other app depends on.
+// The apps in a wave are sorted by name.
+func wavesFromClasses(classes map[string]int) [][]string {
+	maxClass := 0
+	for _, class := range classes {
+		maxClass = max(maxClass, class)
+	}
+
+	waves := make([][]string, maxClass)
+	for app, class := range classes {
+		waves[class-1] = append(waves[class-1], app)
+	}
+
+	for _, wave := range waves {
+		slices.Sort(wave)
+	}
+
+	return waves
+}
+
 // DependencyOrder calculates the dependency order (reverse topological order)
 // for the given distribution and returns it as graph in the dot format.
 // Soft dependency are marked with dotted edges.
@@ -249,9 +391,40 @@ func (c *Composition) DependencyOrder(distribution string, apps ...string) ([]st
 // Different from DependencyOrder, not error is returned if a loop exist between
 // hard dependencies, the loop shows up in the dot graph.
 func (c *Composition) DependencyOrderDot(distribution string, apps ...string) (string, error) {
+	graph, err := c.dependencyOrderDot(distribution, apps)
+	if err != nil {
+		return "", err
+	}
+
+	return graph.String(), nil
+}
+
+// dependencyOrderDot returns the dot graph of DependencyOrderDot.
+func (c *Composition) dependencyOrderDot(distribution string, apps []string) (*graphs.Dot, error) {
 	graph := graphs.NewDotDiGraph()
+	if err := c.DependencyGraph(graph, distribution, apps...); err != nil {
+		return nil, err
+	}
 
-	err := c.forEach(distribution, apps,
+	return graph, nil
+}
+
+// GraphEdgeLabels are the labels of the edges that DependencyGraph adds, for
+// graph formats that label edges.
+var GraphEdgeLabels = graphs.EdgeLabels{
+	Edge:       cfg.TypeHardDependency,
+	DottedEdge: cfg.TypeSoftDependency,
+}
+
+// DependencyGraph adds the apps of the distribution and their dependencies to
+// graph. Hard dependencies are added as edges, soft dependencies as dotted
+// edges.
+// If apps is not empty, only the given app names and their recursive
+// dependencies are added.
+// If an app name is not part of the distribution and error is returned.
+// Loops between hard dependencies are not an error.
+func (c *Composition) DependencyGraph(graph graphs.GraphWriter, distribution string, apps ...string) error {
+	return c.forEach(distribution, apps,
 		func(appName string, deps *Dependencies) error {
 			if err := graph.AddNode(appName); err != nil {
 				return fmt.Errorf("could not add node %v to graph: %w", appName, err)
@@ -279,12 +452,43 @@ func (c *Composition) DependencyOrderDot(distribution string, apps ...string) (s
 
 			return nil
 		})
+}
+
+// Edges returns the hard- and soft dependencies between the apps of the
+// distribution, sorted by the names of the apps.
+// If apps is not empty, only the edges of the given apps and their recursive
+// dependencies are returned.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) Edges(distribution string, apps ...string) ([]*Edge, error) {
+	var res []*Edge
 
+	err := c.forEach(distribution, apps,
+		func(appName string, deps *Dependencies) error {
+			for _, hd := range deps.HardDeps {
+				res = append(res, c.edge(distribution, appName, hd, cfg.TypeHardDependency))
+			}
+			for _, sd := range deps.SoftDeps {
+				res = append(res, c.edge(distribution, appName, sd, cfg.TypeSoftDependency))
+			}
+			return nil
+		})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return graph.String(), nil
+	slices.SortFunc(res, compareEdges)
+
+	return res, nil
+}
+
+func compareEdges(a, b *Edge) int {
+	if r := strings.Compare(a.From, b.From); r != 0 {
+		return r
+	}
+	if r := strings.Compare(a.To, b.To); r != 0 {
+		return r
+	}
+	return strings.Compare(a.Type, b.Type)
 }
 
 func (c *Composition) IsEmpty() bool {
@@ -1,11 +1,18 @@
 package deps
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
 	"github.com/simplesurance/dependencies-tool/v3/internal/testutils"
 )
 
@@ -88,6 +95,90 @@ func TestDeploymentOrder(t *testing.T) {
 	})
 }
 
+func TestDependencyWaves(t *testing.T) {
+	/*
+		Dependency structure:
+		* means soft-dependency
+		m -> m1
+		m1 -> a, b
+		a
+		b -> c*
+		c -> d
+		d
+		x
+	*/
+	comp := NewComposition()
+	comp.Add("prd", "m", &Dependencies{HardDeps: []string{"m1"}})
+	comp.Add("prd", "m1", &Dependencies{HardDeps: []string{"a", "b"}})
+	comp.Add("prd", "a", &Dependencies{})
+	comp.Add("prd", "b", &Dependencies{SoftDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{})
+	comp.Add("prd", "x", &Dependencies{})
+
+	t.Run("all", func(t *testing.T) {
+		waves, err := comp.DependencyWaves("prd")
+		require.NoError(t, err)
+		t.Log(waves)
+
+		waveOf := map[string]int{}
+		for i, wave := range waves {
+			for _, app := range wave {
+				require.NotContains(t, waveOf, app)
+				waveOf[app] = i
+			}
+		}
+		require.Len(t, waveOf, 7)
+
+		assert.Less(t, waveOf["m1"], waveOf["m"])
+		assert.Less(t, waveOf["a"], waveOf["m1"])
+		assert.Less(t, waveOf["b"], waveOf["m1"])
+		assert.Less(t, waveOf["d"], waveOf["c"])
+		assert.Len(t, waves, 3)
+	})
+
+	t.Run("apps_without_hard_dependencies_in_first_wave", func(t *testing.T) {
+		waves, err := comp.DependencyWaves("prd")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"a", "b", "d", "x"}, {"c", "m1"}, {"m"}}, waves)
+	})
+
+	t.Run("selected_apps", func(t *testing.T) {
+		waves, err := comp.DependencyWaves("prd", "c")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"d"}, {"c"}}, waves)
+	})
+}
+
+func TestTeardownOrder(t *testing.T) {
+	// m -> m1, m1 -> a, b, b -> c*, c -> d
+	comp := NewComposition()
+	comp.Add("prd", "m", &Dependencies{HardDeps: []string{"m1"}})
+	comp.Add("prd", "m1", &Dependencies{HardDeps: []string{"a", "b"}})
+	comp.Add("prd", "a", &Dependencies{})
+	comp.Add("prd", "b", &Dependencies{SoftDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{})
+
+	t.Run("order", func(t *testing.T) {
+		order, err := comp.TeardownOrder("prd")
+		require.NoError(t, err)
+		t.Log(order)
+
+		testutils.After(t, order, "m1", "m")
+		testutils.After(t, order, "a", "m1")
+		testutils.After(t, order, "b", "m1")
+		testutils.After(t, order, "d", "c")
+		require.Len(t, order, 6)
+	})
+
+	t.Run("waves_selected_apps", func(t *testing.T) {
+		waves, err := comp.TeardownWaves("prd", "b")
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{{"b", "c"}, {"d"}}, waves)
+	})
+}
+
 func TestHardDepLoopNotAllowed(t *testing.T) {
 	comp := NewComposition()
 	comp.Add("prd", "m", &Dependencies{HardDeps: []string{"m1"}})
@@ -97,6 +188,30 @@ func TestHardDepLoopNotAllowed(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestHardDepLoopIsReported(t *testing.T) {
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, SourceFile: "a/deps.yaml"})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}, SourceFile: "b/deps.yaml"})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"a"}, SourceFile: "c/deps.yaml"})
+	comp.Add("prd", "d", &Dependencies{HardDeps: []string{"a", "d"}, SourceFile: "d/deps.yaml"})
+	comp.Add("prd", "e", &Dependencies{SoftDeps: []string{"f"}})
+	comp.Add("prd", "f", &Dependencies{SoftDeps: []string{"e"}})
+
+	_, err := comp.DependencyOrder("prd")
+	require.ErrorIs(t, err, graphs.ErrNoDAG)
+	t.Log(err)
+
+	var cycleErr *CycleError
+	require.ErrorAs(t, err, &cycleErr)
+	require.Len(t, cycleErr.Cycles, 2)
+	assert.Equal(t, "a -> b -> c -> a", cycleErr.Cycles[0].String())
+	assert.Equal(t, "d -> d", cycleErr.Cycles[1].String())
+	assert.Equal(t, "b/deps.yaml", cycleErr.Cycles[0][1].SourceFile)
+
+	require.ErrorAs(t, comp.VerifyAcyclic(), &cycleErr)
+	assert.Equal(t, "prd", cycleErr.Distribution)
+}
+
 func TestSofDepLoopAllowed(t *testing.T) {
 	comp := NewComposition()
 	comp.Add("prd", "m", &Dependencies{SoftDeps: []string{"m1"}})
@@ -146,3 +261,470 @@ func TestOutputDotGraph(t *testing.T) {
 	})
 
 }
+
+func TestCompositionFromDirDuplicateApps(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	writeFile(filepath.Join("a", "deps.yaml"), "name: a\ndependencies:\n  prd: ~\n")
+	writeFile(filepath.Join("b", "deps.yaml"), "name: a\ndependencies:\n  stg: ~\n")
+
+	t.Run("duplicates_not_allowed", func(t *testing.T) {
+		_, err := CompositionFromDir(dir, "deps.yaml", nil, false)
+		require.Error(t, err)
+		t.Log(err)
+		assert.Contains(t, err.Error(), filepath.Join("a", "deps.yaml"))
+		assert.Contains(t, err.Error(), filepath.Join("b", "deps.yaml"))
+	})
+
+	t.Run("merge_disjoint_distributions", func(t *testing.T) {
+		comp, err := CompositionFromDir(dir, "deps.yaml", nil, true)
+		require.NoError(t, err)
+		assert.Contains(t, comp.Distribution["prd"], "a")
+		assert.Contains(t, comp.Distribution["stg"], "a")
+	})
+
+	t.Run("merge_overlapping_distributions", func(t *testing.T) {
+		writeFile(filepath.Join("c", "deps.yaml"), "name: a\ndependencies:\n  prd: ~\n")
+		_, err := CompositionFromDir(dir, "deps.yaml", nil, true)
+		require.Error(t, err)
+		t.Log(err)
+		assert.Contains(t, err.Error(), `distribution "prd"`)
+		assert.NotContains(t, err.Error(), `distribution "stg"`)
+	})
+}
+
+func TestDependents(t *testing.T) {
+	/*
+		Dependency structure:
+		* means soft-dependency
+		a -> b
+		b -> c
+		d -> c*
+		e -> d
+		f -> a, e
+	*/
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{})
+	comp.Add("prd", "d", &Dependencies{SoftDeps: []string{"c"}})
+	comp.Add("prd", "e", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "f", &Dependencies{HardDeps: []string{"a", "e"}})
+
+	t.Run("all", func(t *testing.T) {
+		dependents, err := comp.Dependents("prd", "c", 0)
+		require.NoError(t, err)
+		assert.Equal(t, []*Dependent{
+			{Name: "b", Depth: 1, Type: "hard"},
+			{Name: "d", Depth: 1, Type: "soft"},
+			{Name: "a", Depth: 2, Type: "hard"},
+			{Name: "e", Depth: 2, Type: "soft"},
+			{Name: "f", Depth: 3, Type: "hard"},
+		}, dependents)
+	})
+
+	t.Run("max_depth", func(t *testing.T) {
+		dependents, err := comp.Dependents("prd", "c", 2)
+		require.NoError(t, err)
+		assert.Len(t, dependents, 4)
+		assert.NotContains(t, dependents, &Dependent{Name: "f", Depth: 3, Type: "hard"})
+	})
+
+	t.Run("max_depth_type_of_longer_hard_path", func(t *testing.T) {
+		// g -> c*, g -> b -> c
+		comp := NewComposition()
+		comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+		comp.Add("prd", "c", &Dependencies{})
+		comp.Add("prd", "g", &Dependencies{HardDeps: []string{"b"}, SoftDeps: []string{"c"}})
+
+		dependents, err := comp.Dependents("prd", "c", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []*Dependent{
+			{Name: "b", Depth: 1, Type: "hard"},
+			{Name: "g", Depth: 1, Type: "hard"},
+		}, dependents)
+	})
+
+	t.Run("unknown_app", func(t *testing.T) {
+		_, err := comp.Dependents("prd", "x", 0)
+		require.Error(t, err)
+	})
+}
+
+func TestRestrictPreservesTransitiveOrder(t *testing.T) {
+	// a -> b -> c -> d, d -> e*
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{SoftDeps: []string{"e"}})
+	comp.Add("prd", "e", &Dependencies{})
+
+	restricted, err := comp.Restrict("prd", []string{"a", "d", "e"})
+	require.NoError(t, err)
+
+	distr := restricted.Distribution["prd"]
+	require.Len(t, distr, 3)
+	assert.Equal(t, []string{"d"}, distr["a"].HardDeps)
+	assert.Equal(t, []string{"e"}, distr["d"].SoftDeps)
+
+	order, err := restricted.DependencyOrder("prd")
+	require.NoError(t, err)
+	testutils.After(t, order, "a", "d")
+}
+
+func TestOwningApps(t *testing.T) {
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{SourceFile: filepath.Join("svc", "a", "deploy", "deps.yaml")})
+	comp.Add("prd", "b", &Dependencies{SourceFile: filepath.Join("svc", "a", "b", "deploy", "deps.yaml")})
+	comp.Add("prd", "c", &Dependencies{SourceFile: filepath.Join("svc", "c", "deploy", "deps.yaml")})
+
+	owners, unowned, err := comp.OwningApps("prd", filepath.Join("deploy", "deps.yaml"), []string{
+		filepath.Join("svc", "a", "main.go"),
+		filepath.Join("svc", "a", "b", "pkg", "b.go"),
+		filepath.Join("svc", "a", "deploy", "deps.yaml"),
+		"README.md",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, owners)
+	assert.Equal(t, []string{"README.md"}, unowned)
+}
+
+func TestEstimateRollout(t *testing.T) {
+	/*
+		Dependency structure:
+		* means soft-dependency
+		a (1m) -> b, c
+		b (5m) -> d
+		c (2m) -> d
+		d (1m)
+		e (3m) -> a*
+	*/
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b", "c"}, DeployDuration: time.Minute})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"d"}, DeployDuration: 5 * time.Minute})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}, DeployDuration: 2 * time.Minute})
+	comp.Add("prd", "d", &Dependencies{DeployDuration: time.Minute})
+	comp.Add("prd", "e", &Dependencies{SoftDeps: []string{"a"}})
+
+	estimate, err := comp.EstimateRollout("prd", 3*time.Minute, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"d", "b", "a"}, estimate.CriticalPath)
+	assert.Equal(t, 7*time.Minute, estimate.Duration)
+	assert.Equal(t, 12*time.Minute, estimate.WorkersDuration)
+
+	starts := map[string]time.Duration{}
+	for _, app := range estimate.Apps {
+		starts[app.Name] = app.EarliestStart
+	}
+	assert.Equal(t, map[string]time.Duration{
+		"a": 6 * time.Minute,
+		"b": time.Minute,
+		"c": time.Minute,
+		"d": 0,
+		"e": 0,
+	}, starts)
+}
+
+func TestDurationsAreJSONEncodedAsStrings(t *testing.T) {
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, DeployDuration: 5*time.Minute + 30*time.Second})
+	comp.Add("prd", "b", &Dependencies{})
+
+	var buf bytes.Buffer
+	require.NoError(t, comp.ToJSON(&buf))
+	assert.Contains(t, buf.String(), `"deploy_duration":"5m30s"`)
+	assert.NotContains(t, buf.String(), `"deploy_duration":"0s"`)
+
+	path := filepath.Join(t.TempDir(), "deps.json")
+	require.NoError(t, comp.ToJSONFile(path))
+	decoded, err := CompositionFromJSON(path)
+	require.NoError(t, err)
+	assert.Equal(t, comp, decoded)
+
+	estimate, err := comp.EstimateRollout("prd", time.Minute, 1)
+	require.NoError(t, err)
+	out, err := json.Marshal(estimate)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"duration":"6m30s"`)
+	assert.Contains(t, string(out), `"earliest_start":"1m0s"`)
+	assert.Contains(t, string(out), `"workers_duration":"6m30s"`)
+}
+
+func TestRedundantHardDependencies(t *testing.T) {
+	// a -> b, c, d; b -> c; c -> d; a -> e*; e -> d
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b", "c", "d"}, SoftDeps: []string{"e"}, SourceFile: "a/deps.yaml"})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{})
+	comp.Add("prd", "e", &Dependencies{HardDeps: []string{"d"}})
+
+	redundant, err := comp.RedundantHardDependencies("prd")
+	require.NoError(t, err)
+	assert.Equal(t, []*Edge{
+		{From: "a", To: "c", Type: "hard", SourceFile: "a/deps.yaml"},
+		{From: "a", To: "d", Type: "hard", SourceFile: "a/deps.yaml"},
+	}, redundant)
+
+	reduced, err := comp.TransitiveReduction("prd")
+	require.NoError(t, err)
+	dot, err := reduced.DependencyOrderDot("prd")
+	require.NoError(t, err)
+	assert.Contains(t, dot, "a->b")
+	assert.Contains(t, dot, "e->d")
+	assert.NotContains(t, dot, "a->c")
+	assert.NotContains(t, dot, "a->d")
+}
+
+func TestDependencyOrderGrouped(t *testing.T) {
+	/*
+		Dependency structure:
+		* means soft-dependency
+		a -> b*
+		b -> a*, c
+		c -> d
+		d
+		e -> a
+	*/
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{SoftDeps: []string{"b"}})
+	comp.Add("prd", "b", &Dependencies{SoftDeps: []string{"a"}, HardDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{})
+	comp.Add("prd", "e", &Dependencies{HardDeps: []string{"a"}})
+
+	clusters, err := comp.SoftDependencyClusters("prd")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}}, clusters)
+
+	units, err := comp.DependencyOrderGrouped("prd")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"d"}, {"c"}, {"a", "b"}, {"e"}}, units)
+
+	dot, err := comp.DependencyOrderDotGrouped("prd")
+	require.NoError(t, err)
+	assert.Contains(t, dot, "subgraph cluster_0")
+
+	t.Run("unit_in_hard_loop", func(t *testing.T) {
+		// a and b can not be deployed together, c must be deployed
+		// after a and before b
+		comp.Add("prd", "c", &Dependencies{HardDeps: []string{"a"}})
+		_, err := comp.DependencyOrderGrouped("prd")
+		require.Error(t, err)
+		t.Log(err)
+		assert.Contains(t, err.Error(), "c -> {a, b} -> c")
+	})
+}
+
+func TestDependencyPaths(t *testing.T) {
+	/*
+		Dependency structure:
+		* means soft-dependency
+		a -> b, c*
+		b -> d
+		c -> d
+		d -> e
+		e -> b*
+	*/
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, SoftDeps: []string{"c"}})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"d"}})
+	comp.Add("prd", "d", &Dependencies{HardDeps: []string{"e"}})
+	comp.Add("prd", "e", &Dependencies{SoftDeps: []string{"b"}})
+
+	toStrings := func(paths []DependencyPath) []string {
+		var res []string
+		for _, p := range paths {
+			res = append(res, p.String())
+		}
+		return res
+	}
+
+	t.Run("all", func(t *testing.T) {
+		paths, err := comp.DependencyPaths("prd", "a", "e", false, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a -> b -> d -> e", "a -> c -> d -> e"}, toStrings(paths))
+		assert.Equal(t, "soft", paths[1][0].Type)
+	})
+
+	t.Run("shortest", func(t *testing.T) {
+		paths, err := comp.DependencyPaths("prd", "a", "d", true, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a -> b -> d", "a -> c -> d"}, toStrings(paths))
+	})
+
+	t.Run("max_paths", func(t *testing.T) {
+		paths, err := comp.DependencyPaths("prd", "a", "d", false, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a -> b -> d"}, toStrings(paths))
+	})
+
+	t.Run("no_path", func(t *testing.T) {
+		paths, err := comp.DependencyPaths("prd", "d", "a", false, 0)
+		require.NoError(t, err)
+		assert.Empty(t, paths)
+	})
+
+	t.Run("max_paths_returns_shortest", func(t *testing.T) {
+		comp := NewComposition()
+		comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b", "z"}})
+		comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+		comp.Add("prd", "c", &Dependencies{HardDeps: []string{"z"}})
+		comp.Add("prd", "z", &Dependencies{})
+
+		paths, err := comp.DependencyPaths("prd", "a", "z", false, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a -> z"}, toStrings(paths))
+	})
+}
+
+func TestDependencyPathsDenseGraph(t *testing.T) {
+	// every app depends on all apps with a higher number, the number of
+	// paths from app-000 to app-299 is 2^298
+	const apps = 300
+	comp := NewComposition()
+	for i := range apps {
+		var hardDeps []string
+		for j := i + 1; j < apps; j++ {
+			hardDeps = append(hardDeps, fmt.Sprintf("app-%03d", j))
+		}
+		comp.Add("prd", fmt.Sprintf("app-%03d", i), &Dependencies{HardDeps: hardDeps})
+	}
+	comp.Add("prd", "unreachable", &Dependencies{HardDeps: []string{"app-000"}})
+
+	paths, err := comp.DependencyPaths("prd", "app-000", "app-299", false, 3)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+	assert.Equal(t, "app-000 -> app-299", paths[0].String())
+	assert.Equal(t, "app-000 -> app-001 -> app-299", paths[1].String())
+	assert.Equal(t, "app-000 -> app-002 -> app-299", paths[2].String())
+
+	paths, err = comp.DependencyPaths("prd", "app-000", "unreachable", false, 0)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestDiff(t *testing.T) {
+	oldComp := NewComposition()
+	oldComp.Add("prd", "a", &Dependencies{HardDeps: []string{"b", "c"}})
+	oldComp.Add("prd", "b", &Dependencies{})
+	oldComp.Add("prd", "c", &Dependencies{})
+	oldComp.Add("stg", "a", &Dependencies{})
+
+	newComp := NewComposition()
+	newComp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, SoftDeps: []string{"d"}})
+	newComp.Add("prd", "b", &Dependencies{SoftDeps: []string{"d"}})
+	newComp.Add("prd", "d", &Dependencies{HardDeps: []string{"b"}})
+	newComp.Add("testing", "a", &Dependencies{})
+
+	diff := Diff(oldComp, newComp)
+	assert.Equal(t, []string{"testing"}, diff.AddedDistributions)
+	assert.Equal(t, []string{"stg"}, diff.RemovedDistributions)
+	require.Len(t, diff.Distributions, 3)
+
+	prd := diff.Distributions[0]
+	assert.Equal(t, "prd", prd.Distribution)
+	assert.Equal(t, []string{"d"}, prd.AddedApps)
+	assert.Equal(t, []string{"c"}, prd.RemovedApps)
+	assert.Equal(t, []*Edge{
+		{From: "a", To: "d", Type: "soft"},
+		{From: "b", To: "d", Type: "soft"},
+		{From: "d", To: "b", Type: "hard"},
+	}, prd.AddedEdges)
+	assert.Equal(t, []*Edge{{From: "a", To: "c", Type: "hard"}}, prd.RemovedEdges)
+	assert.Empty(t, prd.ChangedEdges)
+
+	newComp.Add("prd", "a", &Dependencies{SoftDeps: []string{"b", "c"}})
+	newComp.Add("prd", "c", &Dependencies{})
+	diff = Diff(oldComp, newComp)
+	assert.Equal(t, []*EdgeTypeChange{
+		{From: "a", To: "b", OldType: "hard", NewType: "soft"},
+		{From: "a", To: "c", OldType: "hard", NewType: "soft"},
+	}, diff.Distributions[0].ChangedEdges)
+
+	assert.True(t, Diff(oldComp, oldComp).IsEmpty())
+}
+
+func TestHashIgnoresDependencyOrderAndMetadata(t *testing.T) {
+	comp1 := NewComposition()
+	comp1.Add("prd", "a", &Dependencies{HardDeps: []string{"b", "c"}, SourceFile: "a/deps.yaml"})
+	comp1.Add("prd", "b", &Dependencies{})
+	comp1.Add("prd", "c", &Dependencies{})
+
+	comp2 := NewComposition()
+	comp2.Add("prd", "a", &Dependencies{HardDeps: []string{"c", "b"}, DeployDuration: time.Minute})
+	comp2.Add("prd", "b", &Dependencies{})
+	comp2.Add("prd", "c", &Dependencies{})
+
+	hash1, err := comp1.Hash()
+	require.NoError(t, err)
+	hash2, err := comp2.Hash()
+	require.NoError(t, err)
+	assert.Equal(t, hash1, hash2)
+
+	comp2.Add("prd", "c", &Dependencies{SoftDeps: []string{"b"}})
+	hash2, err = comp2.Hash()
+	require.NoError(t, err)
+	assert.NotEqual(t, hash1, hash2)
+}
+
+func TestGraphDocument(t *testing.T) {
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, SoftDeps: []string{"c"}, SourceFile: "a/deps.yaml"})
+	comp.Add("prd", "b", &Dependencies{})
+	comp.Add("prd", "c", &Dependencies{})
+	comp.Add("prd", "d", &Dependencies{})
+
+	doc, err := comp.GraphDocument("prd", "a")
+	require.NoError(t, err)
+
+	assert.Equal(t, GraphDocumentVersion, doc.Version)
+	assert.Equal(t, "prd", doc.Distribution)
+	assert.Equal(t, []*GraphDocumentNode{
+		{Name: "b", Wave: 1},
+		{Name: "c", Wave: 1},
+		{Name: "a", Wave: 2, SourceFile: "a/deps.yaml"},
+	}, doc.Nodes)
+	assert.Equal(t, []*GraphDocumentEdge{
+		{From: "a", To: "b", Type: "hard"},
+		{From: "a", To: "c", Type: "soft"},
+	}, doc.Edges)
+
+	doc, err = comp.TeardownGraphDocument("prd", "a")
+	require.NoError(t, err)
+	assert.True(t, doc.Teardown)
+	assert.Equal(t, []*GraphDocumentNode{
+		{Name: "a", Wave: 1, SourceFile: "a/deps.yaml"},
+		{Name: "c", Wave: 1},
+		{Name: "b", Wave: 2},
+	}, doc.Nodes)
+}
+
+func TestDSMPartitionsLoops(t *testing.T) {
+	comp := NewComposition()
+	comp.Add("prd", "a", &Dependencies{HardDeps: []string{"b"}, SoftDeps: []string{"d"}})
+	comp.Add("prd", "b", &Dependencies{HardDeps: []string{"c"}})
+	comp.Add("prd", "c", &Dependencies{HardDeps: []string{"b"}})
+	comp.Add("prd", "d", &Dependencies{HardDeps: []string{"a"}})
+
+	dsm, err := comp.DSM("prd")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"b", "c", "a", "d"}, dsm.Apps)
+	assert.Equal(t, []*DSMBlock{{Start: 0, End: 2}}, dsm.Blocks)
+	assert.Equal(t, [][]string{
+		{"", DSMHard, "", ""},
+		{DSMHard, "", "", ""},
+		{DSMHard, "", "", DSMSoft},
+		{"", "", DSMHard, ""},
+	}, dsm.Cells)
+}
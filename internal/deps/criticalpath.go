@@ -0,0 +1,204 @@
+package deps
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AppSchedule is the estimated schedule of an app during a rollout.
+type AppSchedule struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	// EarliestStart is the earliest point in time, relative to the
+	// start of the rollout, at which the deployment of the app can begin,
+	// when all apps can be deployed in parallel.
+	EarliestStart time.Duration `json:"earliest_start"`
+	// Critical is true if the app is part of the critical path.
+	Critical bool `json:"critical"`
+}
+
+// RolloutEstimate is the estimated duration of deploying the apps of a
+// distribution.
+type RolloutEstimate struct {
+	// Apps contains the schedule of all apps, sorted by their earliest
+	// start time and name.
+	Apps []*AppSchedule `json:"apps"`
+	// CriticalPath is the chain of hard dependencies with the longest
+	// total duration, in deployment order.
+	CriticalPath []string `json:"critical_path"`
+	// Duration is the wall-clock time of the rollout when all apps can be
+	// deployed in parallel. It is the total duration of the critical path.
+	Duration time.Duration `json:"duration"`
+	// Workers is the number of apps that can be deployed in parallel that
+	// was used to calculate WorkersDuration. It is 0 if it has not been
+	// calculated.
+	Workers int `json:"workers,omitempty"`
+	// WorkersDuration is the estimated wall-clock time of the rollout when
+	// at most Workers apps are deployed in parallel.
+	WorkersDuration time.Duration `json:"workers_duration,omitempty"`
+}
+
+// EstimateRollout calculates the critical path and the estimated duration of
+// deploying the apps of the distribution.
+// Apps without a DeployDuration are assumed to take defaultDuration.
+// Only hard dependencies constrain the schedule.
+// If workers is greater than 0, the duration of the rollout when at most
+// workers apps are deployed in parallel is estimated additionally, via list
+// scheduling that prefers apps with the longest remaining chain.
+// If apps is not empty, the estimate is only calculated for the given app
+// names and their dependencies instead of all.
+// If a loop exist between hard dependencies a *CycleError is returned.
+func (c *Composition) EstimateRollout(distribution string, defaultDuration time.Duration, workers int, apps ...string) (*RolloutEstimate, error) {
+	if workers < 0 {
+		return nil, errors.New("workers must be 0 or greater")
+	}
+
+	// in topological order dependent apps are ordered before their
+	// dependencies
+	order, _, err := c.topologicalSort(distribution, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	distrDeps := c.Distribution[distribution]
+	durations := make(map[string]time.Duration, len(order))
+	dependents := make(map[string][]string, len(order))
+	for _, app := range order {
+		durations[app] = distrDeps[app].DeployDuration
+		if durations[app] == 0 {
+			durations[app] = defaultDuration
+		}
+		for _, hd := range distrDeps[app].HardDeps {
+			dependents[hd] = append(dependents[hd], app)
+		}
+	}
+
+	starts := make(map[string]time.Duration, len(order))
+	predecessors := map[string]string{}
+	var last string
+	var total time.Duration
+	for _, app := range slices.Backward(order) {
+		for _, hd := range slices.Sorted(slices.Values(distrDeps[app].HardDeps)) {
+			if finish := starts[hd] + durations[hd]; finish > starts[app] || (finish == starts[app] && predecessors[app] == "") {
+				starts[app] = finish
+				predecessors[app] = hd
+			}
+		}
+
+		if finish := starts[app] + durations[app]; last == "" || finish > total {
+			total = finish
+			last = app
+		}
+	}
+
+	var criticalPath []string
+	critical := map[string]struct{}{}
+	for app := last; app != ""; app = predecessors[app] {
+		criticalPath = append(criticalPath, app)
+		critical[app] = struct{}{}
+	}
+	slices.Reverse(criticalPath)
+
+	res := RolloutEstimate{
+		Apps:         make([]*AppSchedule, 0, len(order)),
+		CriticalPath: criticalPath,
+		Duration:     total,
+	}
+	for _, app := range order {
+		_, isCritical := critical[app]
+		res.Apps = append(res.Apps, &AppSchedule{
+			Name:          app,
+			Duration:      durations[app],
+			EarliestStart: starts[app],
+			Critical:      isCritical,
+		})
+	}
+	slices.SortFunc(res.Apps, func(a, b *AppSchedule) int {
+		if a.EarliestStart != b.EarliestStart {
+			return cmp.Compare(a.EarliestStart, b.EarliestStart)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if workers > 0 {
+		res.Workers = workers
+		res.WorkersDuration = simulateRollout(order, distrDeps, dependents, durations, workers)
+	}
+
+	return &res, nil
+}
+
+// simulateRollout returns the duration of deploying the apps when at most
+// workers apps are deployed in parallel.
+// order must be the topological order of the apps.
+// When multiple apps are ready to be deployed, the one with the longest
+// remaining chain of dependents is started first.
+func simulateRollout(
+	order []string,
+	distrDeps map[string]*Dependencies,
+	dependents map[string][]string,
+	durations map[string]time.Duration,
+	workers int,
+) time.Duration {
+	// tails is the duration of the longest chain from the app to the end
+	// of the rollout, including the app itself
+	tails := make(map[string]time.Duration, len(order))
+	for _, app := range order {
+		var tail time.Duration
+		for _, d := range dependents[app] {
+			tail = max(tail, tails[d])
+		}
+		tails[app] = tail + durations[app]
+	}
+
+	pending := make(map[string]int, len(order))
+	var ready []string
+	for _, app := range order {
+		pending[app] = len(distrDeps[app].HardDeps)
+		if pending[app] == 0 {
+			ready = append(ready, app)
+		}
+	}
+
+	type job struct {
+		app    string
+		finish time.Duration
+	}
+
+	var now time.Duration
+	var running []job
+	for len(ready) > 0 || len(running) > 0 {
+		slices.SortFunc(ready, func(a, b string) int {
+			if tails[a] != tails[b] {
+				return cmp.Compare(tails[b], tails[a])
+			}
+			return strings.Compare(a, b)
+		})
+		for len(running) < workers && len(ready) > 0 {
+			running = append(running, job{app: ready[0], finish: now + durations[ready[0]]})
+			ready = ready[1:]
+		}
+
+		now = slices.MinFunc(running, func(a, b job) int {
+			return cmp.Compare(a.finish, b.finish)
+		}).finish
+
+		running = slices.DeleteFunc(running, func(j job) bool {
+			if j.finish != now {
+				return false
+			}
+			for _, d := range dependents[j.app] {
+				pending[d]--
+				if pending[d] == 0 {
+					ready = append(ready, d)
+				}
+			}
+			return true
+		})
+	}
+
+	return now
+}
@@ -0,0 +1,130 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// Cycle is a loop of hard dependencies. The To field of the last edge is
+// the From field of the first edge.
+type Cycle []*Edge
+
+// String returns the cycle in the format "a -> b -> c -> a".
+func (c Cycle) String() string {
+	if len(c) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, e := range c {
+		sb.WriteString(e.From)
+		sb.WriteString(" -> ")
+	}
+	sb.WriteString(c[len(c)-1].To)
+
+	return sb.String()
+}
+
+// CycleError is returned when hard dependencies of a distribution form a loop.
+type CycleError struct {
+	Distribution string
+	Cycles       []Cycle
+}
+
+func (e *CycleError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "hard dependencies of distribution %q contain %d loop(s):", e.Distribution, len(e.Cycles))
+	for _, cycle := range e.Cycles {
+		fmt.Fprintf(&sb, "\n  %s", cycle)
+		for _, edge := range cycle {
+			if edge.SourceFile != "" {
+				fmt.Fprintf(&sb, "\n    %s -> %s declared in %s", edge.From, edge.To, edge.SourceFile)
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns graphs.ErrNoDAG.
+func (e *CycleError) Unwrap() error {
+	return graphs.ErrNoDAG
+}
+
+// HardDependencyCycles returns a loop for every group of apps in the
+// distribution that depend on each other via hard dependencies.
+// If no loops exist, nil is returned.
+func (c *Composition) HardDependencyCycles(distribution string) ([]Cycle, error) {
+	g, err := c.createGraph(distribution, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.cycles(distribution, g), nil
+}
+
+// VerifyAcyclic ensures that the hard dependencies of all distributions do
+// not contain loops.
+// For every distribution that contains loops a *CycleError is returned.
+func (c *Composition) VerifyAcyclic() error {
+	var errs []error
+
+	for _, distr := range slices.Sorted(maps.Keys(c.Distribution)) {
+		cycles, err := c.HardDependencyCycles(distr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", distr, err))
+			continue
+		}
+		if len(cycles) > 0 {
+			errs = append(errs, &CycleError{Distribution: distr, Cycles: cycles})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// newCycleError returns a *CycleError that contains all loops in g.
+func (c *Composition) newCycleError(distribution string, g *graphs.Graph) *CycleError {
+	return &CycleError{
+		Distribution: distribution,
+		Cycles:       c.cycles(distribution, g),
+	}
+}
+
+// cycles returns a loop for each strongly connected component in g, that
+// contains one.
+func (c *Composition) cycles(distribution string, g *graphs.Graph) []Cycle {
+	var res []Cycle
+
+	for _, component := range graphs.StronglyConnectedComponents(g) {
+		vertices := graphs.FindCycle(g, component)
+		if len(vertices) == 0 {
+			continue
+		}
+
+		cycle := make(Cycle, 0, len(vertices))
+		for i, from := range vertices {
+			to := vertices[(i+1)%len(vertices)]
+			cycle = append(cycle, c.edge(distribution, from, to, cfg.TypeHardDependency))
+		}
+		res = append(res, cycle)
+	}
+
+	return res
+}
+
+// edge returns an Edge between the apps from and to in the distribution.
+func (c *Composition) edge(distribution, from, to, depType string) *Edge {
+	e := Edge{From: from, To: to, Type: depType}
+	if deps := c.Distribution[distribution][from]; deps != nil {
+		e.SourceFile = deps.SourceFile
+	}
+	return &e
+}
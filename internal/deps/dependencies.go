@@ -2,6 +2,7 @@ package deps
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
 )
@@ -9,6 +10,43 @@ import (
 type Dependencies struct {
 	SoftDeps []string `json:"soft_dependencies"`
 	HardDeps []string `json:"hard_dependencies"`
+	// SourceFile is the path of the configuration file that defines the
+	// dependencies, relative to the root directory it was discovered in.
+	SourceFile string `json:"source_file,omitempty"`
+	// DeployDuration is the estimated duration of deploying the app, 0
+	// if it is unknown.
+	DeployDuration time.Duration `json:"deploy_duration,omitempty"`
+}
+
+// Edge is a dependency from one app to another.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is either cfg.TypeHardDependency or cfg.TypeSoftDependency.
+	Type string `json:"type"`
+	// SourceFile is the path of the configuration file that declares the
+	// dependency.
+	SourceFile string `json:"source_file,omitempty"`
+}
+
+func (e *Edge) String() string {
+	if e.SourceFile == "" {
+		return fmt.Sprintf("%s -> %s (%s)", e.From, e.To, e.Type)
+	}
+	return fmt.Sprintf("%s -> %s (%s, declared in %s)", e.From, e.To, e.Type, e.SourceFile)
+}
+
+// CfgDependencies converts d to a map value of the cfg.Config.Dependencies
+// map. Hard dependencies have a nil value.
+func (d *Dependencies) CfgDependencies() map[string]*cfg.Attributes {
+	res := make(map[string]*cfg.Attributes, len(d.HardDeps)+len(d.SoftDeps))
+	for _, dep := range d.HardDeps {
+		res[dep] = nil
+	}
+	for _, dep := range d.SoftDeps {
+		res[dep] = &cfg.Attributes{Type: cfg.TypeSoftDependency}
+	}
+	return res
 }
 
 // dependenciesFromCfg converts a map value of the config.Dependencies map to an
@@ -0,0 +1,164 @@
+package deps
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// Dependent is an app that depends directly or transitively on another app.
+type Dependent struct {
+	Name string `json:"name"`
+	// Depth is the number of edges of the shortest dependency path from
+	// the dependent to the app.
+	Depth int `json:"depth"`
+	// Type is cfg.TypeHardDependency if a path that only consists of hard
+	// dependencies exist from the dependent to the app, otherwise it is
+	// cfg.TypeSoftDependency.
+	Type string `json:"type"`
+}
+
+// Dependents returns all apps of the distribution that depend directly or
+// transitively on app.
+// If maxDepth is greater than 0, only dependents with a dependency path of at
+// most maxDepth edges to app are returned.
+// The result is sorted by depth and name.
+// If app is not part of the distribution an error is returned.
+func (c *Composition) Dependents(distribution, app string, maxDepth int) ([]*Dependent, error) {
+	reverse, err := c.reverseEdges(distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, exists := c.Distribution[distribution][app]; !exists {
+		return nil, fmt.Errorf("the app does not exist: %s", app)
+	}
+
+	depths := reverseBFS(reverse, app, maxDepth, false)
+	// the hard-only search is not limited by maxDepth, a dependent with a
+	// short soft path can also have a longer hard path to app
+	hardDepths := reverseBFS(reverse, app, 0, true)
+
+	res := make([]*Dependent, 0, len(depths))
+	for name, depth := range depths {
+		d := Dependent{Name: name, Depth: depth, Type: cfg.TypeSoftDependency}
+		if _, exists := hardDepths[name]; exists {
+			d.Type = cfg.TypeHardDependency
+		}
+		res = append(res, &d)
+	}
+
+	slices.SortFunc(res, func(a, b *Dependent) int {
+		if a.Depth != b.Depth {
+			return a.Depth - b.Depth
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res, nil
+}
+
+// DependentsDot returns a graph in the dot format, that contains app and its
+// dependents, as returned by Dependents.
+// Soft dependency are marked with dotted edges.
+func (c *Composition) DependentsDot(distribution, app string, maxDepth int) (string, error) {
+	dependents, err := c.Dependents(distribution, app, maxDepth)
+	if err != nil {
+		return "", err
+	}
+
+	included := map[string]struct{}{app: {}}
+	for _, d := range dependents {
+		included[d.Name] = struct{}{}
+	}
+
+	edges, err := c.Edges(distribution)
+	if err != nil {
+		return "", err
+	}
+
+	graph := graphs.NewDotDiGraph()
+	if err := graph.AddNode(app); err != nil {
+		return "", fmt.Errorf("could not add node %v to graph: %w", app, err)
+	}
+	for _, d := range dependents {
+		if err := graph.AddNode(d.Name); err != nil {
+			return "", fmt.Errorf("could not add node %v to graph: %w", d.Name, err)
+		}
+	}
+
+	for _, e := range edges {
+		if _, exists := included[e.From]; !exists {
+			continue
+		}
+		if _, exists := included[e.To]; !exists {
+			continue
+		}
+
+		if e.Type == cfg.TypeSoftDependency {
+			err = graph.AddDottedEdge(e.From, e.To)
+		} else {
+			err = graph.AddEdge(e.From, e.To)
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not add edge from %v to %v: %w", e.From, e.To, err)
+		}
+	}
+
+	return graph.String(), nil
+}
+
+// reverseEdges returns a map of map[APP-NAME][]EDGE, containing for every
+// app of the distribution the edges of the apps that depend on it.
+func (c *Composition) reverseEdges(distribution string) (map[string][]*Edge, error) {
+	edges, err := c.Edges(distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]*Edge{}
+	for _, e := range edges {
+		res[e.To] = append(res[e.To], e)
+	}
+
+	return res, nil
+}
+
+// reverseBFS returns the apps that can be reached from start by following
+// the edges in reverse and their distance to start.
+// start is not part of the result.
+// If maxDepth is greater than 0, only apps with a distance of at most
+// maxDepth are returned.
+// If hardOnly is true, only hard dependency edges are followed.
+func reverseBFS(reverse map[string][]*Edge, start string, maxDepth int, hardOnly bool) map[string]int {
+	depths := map[string]int{start: 0}
+	queue := []string{start}
+
+	for len(queue) > 0 {
+		app := queue[0]
+		queue = queue[1:]
+
+		depth := depths[app] + 1
+		if maxDepth > 0 && depth > maxDepth {
+			continue
+		}
+
+		for _, e := range reverse[app] {
+			if hardOnly && e.Type != cfg.TypeHardDependency {
+				continue
+			}
+			if _, visited := depths[e.From]; visited {
+				continue
+			}
+			depths[e.From] = depth
+			queue = append(queue, e.From)
+		}
+	}
+
+	delete(depths, start)
+
+	return depths
+}
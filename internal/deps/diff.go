@@ -0,0 +1,174 @@
+package deps
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// EdgeTypeChange is a dependency between two apps, whose type differs.
+type EdgeTypeChange struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	OldType string `json:"old_type"`
+	NewType string `json:"new_type"`
+	// SourceFile is the path of the configuration file that declares the
+	// new dependency.
+	SourceFile string `json:"source_file,omitempty"`
+}
+
+// DistributionDiff are the differences between the apps of two
+// distributions.
+type DistributionDiff struct {
+	Distribution string            `json:"distribution"`
+	AddedApps    []string          `json:"added_apps"`
+	RemovedApps  []string          `json:"removed_apps"`
+	AddedEdges   []*Edge           `json:"added_edges"`
+	RemovedEdges []*Edge           `json:"removed_edges"`
+	ChangedEdges []*EdgeTypeChange `json:"changed_edges"`
+}
+
+// IsEmpty returns true if the distributions do not differ.
+func (d *DistributionDiff) IsEmpty() bool {
+	return len(d.AddedApps) == 0 &&
+		len(d.RemovedApps) == 0 &&
+		len(d.AddedEdges) == 0 &&
+		len(d.RemovedEdges) == 0 &&
+		len(d.ChangedEdges) == 0
+}
+
+// CompositionDiff are the differences between two compositions.
+type CompositionDiff struct {
+	AddedDistributions   []string `json:"added_distributions"`
+	RemovedDistributions []string `json:"removed_distributions"`
+	// Distributions contains the differences of all distributions that
+	// differ, sorted by name. Added distributions are compared to an empty
+	// distribution, removed distributions to an empty one.
+	Distributions []*DistributionDiff `json:"distributions"`
+}
+
+// IsEmpty returns true if the compositions do not differ.
+func (d *CompositionDiff) IsEmpty() bool {
+	return len(d.AddedDistributions) == 0 &&
+		len(d.RemovedDistributions) == 0 &&
+		len(d.Distributions) == 0
+}
+
+// Diff returns the differences between the compositions oldComp and newComp.
+func Diff(oldComp, newComp *Composition) *CompositionDiff {
+	res := CompositionDiff{
+		AddedDistributions:   []string{},
+		RemovedDistributions: []string{},
+		Distributions:        []*DistributionDiff{},
+	}
+
+	distrs := map[string]struct{}{}
+	for distr := range oldComp.Distribution {
+		distrs[distr] = struct{}{}
+	}
+	for distr := range newComp.Distribution {
+		distrs[distr] = struct{}{}
+	}
+
+	for _, distr := range slices.Sorted(maps.Keys(distrs)) {
+		oldApps, inOld := oldComp.Distribution[distr]
+		newApps, inNew := newComp.Distribution[distr]
+		switch {
+		case !inOld:
+			res.AddedDistributions = append(res.AddedDistributions, distr)
+		case !inNew:
+			res.RemovedDistributions = append(res.RemovedDistributions, distr)
+		}
+
+		d := DiffApps(distr, oldApps, newApps)
+		if !d.IsEmpty() {
+			res.Distributions = append(res.Distributions, d)
+		}
+	}
+
+	return &res
+}
+
+// DiffApps returns the differences between the apps oldApps and newApps of a
+// distribution. distribution is only used to set the
+// DistributionDiff.Distribution field.
+func DiffApps(distribution string, oldApps, newApps map[string]*Dependencies) *DistributionDiff {
+	res := DistributionDiff{
+		Distribution: distribution,
+		AddedApps:    []string{},
+		RemovedApps:  []string{},
+		AddedEdges:   []*Edge{},
+		RemovedEdges: []*Edge{},
+		ChangedEdges: []*EdgeTypeChange{},
+	}
+
+	for _, app := range slices.Sorted(maps.Keys(newApps)) {
+		if _, exists := oldApps[app]; !exists {
+			res.AddedApps = append(res.AddedApps, app)
+		}
+	}
+	for _, app := range slices.Sorted(maps.Keys(oldApps)) {
+		if _, exists := newApps[app]; !exists {
+			res.RemovedApps = append(res.RemovedApps, app)
+		}
+	}
+
+	oldEdges := appEdges(oldApps)
+	newEdges := appEdges(newApps)
+
+	for _, key := range sortedEdgeKeys(newEdges) {
+		newEdge := newEdges[key]
+		oldEdge, exists := oldEdges[key]
+		switch {
+		case !exists:
+			res.AddedEdges = append(res.AddedEdges, newEdge)
+		case oldEdge.Type != newEdge.Type:
+			res.ChangedEdges = append(res.ChangedEdges, &EdgeTypeChange{
+				From:       key.Start,
+				To:         key.End,
+				OldType:    oldEdge.Type,
+				NewType:    newEdge.Type,
+				SourceFile: newEdge.SourceFile,
+			})
+		}
+	}
+	for _, key := range sortedEdgeKeys(oldEdges) {
+		if _, exists := newEdges[key]; !exists {
+			res.RemovedEdges = append(res.RemovedEdges, oldEdges[key])
+		}
+	}
+
+	return &res
+}
+
+// appEdges returns the dependencies of apps, indexed by the names of the
+// apps they connect.
+func appEdges(apps map[string]*Dependencies) map[graphs.Edge]*Edge {
+	res := map[graphs.Edge]*Edge{}
+	for app, deps := range apps {
+		for _, hd := range deps.HardDeps {
+			res[graphs.Edge{Start: app, End: hd}] = &Edge{
+				From: app, To: hd, Type: cfg.TypeHardDependency, SourceFile: deps.SourceFile,
+			}
+		}
+		for _, sd := range deps.SoftDeps {
+			res[graphs.Edge{Start: app, End: sd}] = &Edge{
+				From: app, To: sd, Type: cfg.TypeSoftDependency, SourceFile: deps.SourceFile,
+			}
+		}
+	}
+	return res
+}
+
+func sortedEdgeKeys(m map[graphs.Edge]*Edge) []graphs.Edge {
+	res := make([]graphs.Edge, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	slices.SortFunc(res, func(a, b graphs.Edge) int {
+		return compareEdges(&Edge{From: a.Start, To: a.End}, &Edge{From: b.Start, To: b.End})
+	})
+	return res
+}
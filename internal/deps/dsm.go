@@ -0,0 +1,127 @@
+package deps
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// Cell values of a DSM.
+const (
+	DSMHard = "H"
+	DSMSoft = "S"
+)
+
+// DSM is a design structure matrix of the dependencies of a distribution.
+type DSM struct {
+	Distribution string `json:"distribution"`
+	// Apps are the rows and columns of the matrix, in dependency order.
+	// Apps that depend on each other via hard dependency loops are
+	// adjacent and sorted by name.
+	Apps []string `json:"apps"`
+	// Cells[i][j] is DSMHard if Apps[i] has a hard dependency on Apps[j],
+	// DSMSoft if it has a soft dependency on it, otherwise it is empty.
+	Cells [][]string `json:"cells"`
+	// Blocks are the groups of apps that depend on each other via hard
+	// dependency loops.
+	Blocks []*DSMBlock `json:"blocks"`
+}
+
+// DSMBlock is a range of rows and columns of a DSM.
+type DSMBlock struct {
+	// Start is the index of the first app of the block in DSM.Apps.
+	Start int `json:"start"`
+	// End is the index after the last app of the block in DSM.Apps.
+	End int `json:"end"`
+}
+
+// DSM returns the design structure matrix of the distribution.
+// The apps are ordered like by DependencyOrder, dependencies come before the
+// apps that depend on them. Hard dependencies are therefore below the
+// diagonal, except for hard dependency loops. Apps that are part of the same
+// loop are partitioned into one block, blocks are ordered as a unit.
+// If apps is not empty, the matrix only contains the given app names and
+// their recursive dependencies.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) DSM(distribution string, apps ...string) (*DSM, error) {
+	hardGraph := graphs.NewDigraph()
+	distrDeps := map[string]*Dependencies{}
+
+	err := c.forEach(distribution, apps,
+		func(appName string, deps *Dependencies) error {
+			distrDeps[appName] = deps
+			hardGraph.AddVertex(appName)
+			for _, hd := range deps.HardDeps {
+				hardGraph.AddEdge(appName, hd)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	// every strongly connected component of the hard dependency graph
+	// is contracted to a unit, named like its first app
+	units := make(map[string]string, len(distrDeps))
+	members := map[string][]string{}
+	for _, component := range graphs.StronglyConnectedComponents(hardGraph) {
+		for _, app := range component {
+			units[app] = component[0]
+		}
+		members[component[0]] = component
+	}
+
+	g := graphs.NewDigraph()
+	g.AddVertex(rootVertexName)
+	for _, app := range slices.Sorted(maps.Keys(distrDeps)) {
+		unit := units[app]
+		g.AddEdge(rootVertexName, unit)
+		for _, hd := range distrDeps[app].HardDeps {
+			if units[hd] != unit {
+				g.AddEdge(unit, units[hd])
+			}
+		}
+	}
+
+	sorted, _, err := graphs.TopologicalSort(g)
+	if err != nil {
+		// the graph of the strongly connected components is acyclic
+		return nil, fmt.Errorf("sorting the dependency graph failed: %w", err)
+	}
+
+	unitOrder := datastructs.ListToSlice(sorted)[1:]
+	slices.Reverse(unitOrder)
+
+	dsm := DSM{
+		Distribution: distribution,
+		Apps:         make([]string, 0, len(distrDeps)),
+		Blocks:       []*DSMBlock{},
+	}
+	for _, unit := range unitOrder {
+		if len(members[unit]) > 1 {
+			dsm.Blocks = append(dsm.Blocks, &DSMBlock{Start: len(dsm.Apps), End: len(dsm.Apps) + len(members[unit])})
+		}
+		dsm.Apps = append(dsm.Apps, members[unit]...)
+	}
+
+	idx := make(map[string]int, len(dsm.Apps))
+	for i, app := range dsm.Apps {
+		idx[app] = i
+	}
+
+	dsm.Cells = make([][]string, len(dsm.Apps))
+	for i, app := range dsm.Apps {
+		dsm.Cells[i] = make([]string, len(dsm.Apps))
+		for _, sd := range distrDeps[app].SoftDeps {
+			dsm.Cells[i][idx[sd]] = DSMSoft
+		}
+		for _, hd := range distrDeps[app].HardDeps {
+			dsm.Cells[i][idx[hd]] = DSMHard
+		}
+	}
+
+	return &dsm, nil
+}
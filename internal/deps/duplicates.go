@@ -0,0 +1,70 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// definitionTracker records in which files apps are defined, to detect
+// apps that are defined multiple times.
+type definitionTracker struct {
+	// files is a map of map[APP-NAME][]FILE-PATH
+	files map[string][]string
+	// distributions is a map of map[APP-NAME]map[DISTRIBUTION-NAME][]FILE-PATH
+	distributions map[string]map[string][]string
+}
+
+func newDefinitionTracker() *definitionTracker {
+	return &definitionTracker{
+		files:         map[string][]string{},
+		distributions: map[string]map[string][]string{},
+	}
+}
+
+// add records that the distribution of appName is defined in path.
+func (t *definitionTracker) add(appName, distribution, path string) {
+	if !slices.Contains(t.files[appName], path) {
+		t.files[appName] = append(t.files[appName], path)
+	}
+
+	distrs := t.distributions[appName]
+	if distrs == nil {
+		distrs = map[string][]string{}
+		t.distributions[appName] = distrs
+	}
+	distrs[distribution] = append(distrs[distribution], path)
+}
+
+// verify returns an error for every app that is defined in more than one
+// file.
+// If mergeDuplicates is true, an error is only returned for apps that
+// define the same distribution in more than one file.
+func (t *definitionTracker) verify(mergeDuplicates bool) error {
+	var errs []error
+
+	for _, appName := range slices.Sorted(maps.Keys(t.files)) {
+		files := t.files[appName]
+		if len(files) < 2 {
+			continue
+		}
+
+		if !mergeDuplicates {
+			errs = append(errs, fmt.Errorf("app %q is defined in multiple files: %s",
+				appName, strings.Join(files, ", ")))
+			continue
+		}
+
+		distrs := t.distributions[appName]
+		for _, distr := range slices.Sorted(maps.Keys(distrs)) {
+			if files := distrs[distr]; len(files) > 1 {
+				errs = append(errs, fmt.Errorf("app %q is defined for distribution %q in multiple files: %s",
+					appName, distr, strings.Join(files, ", ")))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package deps
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/jsontime"
+)
+
+// MarshalJSON encodes d, DeployDuration is encoded as jsontime.Duration.
+func (d Dependencies) MarshalJSON() ([]byte, error) {
+	type plain Dependencies
+	return json.Marshal(struct {
+		plain
+		DeployDuration jsontime.Duration `json:"deploy_duration,omitempty"`
+	}{plain(d), jsontime.Duration(d.DeployDuration)})
+}
+
+// UnmarshalJSON decodes d, DeployDuration is decoded as jsontime.Duration.
+func (d *Dependencies) UnmarshalJSON(b []byte) error {
+	type plain Dependencies
+	v := struct {
+		*plain
+		DeployDuration jsontime.Duration `json:"deploy_duration"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	d.DeployDuration = time.Duration(v.DeployDuration)
+	return nil
+}
+
+// MarshalJSON encodes s, the durations are encoded as jsontime.Duration.
+func (s AppSchedule) MarshalJSON() ([]byte, error) {
+	type plain AppSchedule
+	return json.Marshal(struct {
+		plain
+		Duration      jsontime.Duration `json:"duration"`
+		EarliestStart jsontime.Duration `json:"earliest_start"`
+	}{plain(s), jsontime.Duration(s.Duration), jsontime.Duration(s.EarliestStart)})
+}
+
+// MarshalJSON encodes e, the durations are encoded as jsontime.Duration.
+func (e RolloutEstimate) MarshalJSON() ([]byte, error) {
+	type plain RolloutEstimate
+	return json.Marshal(struct {
+		plain
+		Duration        jsontime.Duration `json:"duration"`
+		WorkersDuration jsontime.Duration `json:"workers_duration,omitempty"`
+	}{plain(e), jsontime.Duration(e.Duration), jsontime.Duration(e.WorkersDuration)})
+}
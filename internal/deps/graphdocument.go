@@ -0,0 +1,95 @@
+package deps
+
+// GraphDocumentVersion is the version of the GraphDocument format.
+// It is increased when fields are removed or their meaning changes.
+const GraphDocumentVersion = 1
+
+// GraphDocument is a serializable representation of the dependency graph of
+// a distribution.
+type GraphDocument struct {
+	Version      int    `json:"version"`
+	Distribution string `json:"distribution"`
+	// Teardown is true if the waves of the nodes are teardown waves.
+	Teardown bool `json:"teardown,omitempty"`
+	// Nodes are sorted by wave and name.
+	Nodes []*GraphDocumentNode `json:"nodes"`
+	// Edges are sorted by the names of the apps.
+	Edges []*GraphDocumentEdge `json:"edges"`
+}
+
+// GraphDocumentNode is an app in a GraphDocument.
+type GraphDocumentNode struct {
+	Name string `json:"name"`
+	// Wave is the number of the deployment wave of the app, starting at 1.
+	// If GraphDocument.Teardown is true, it is the number of the teardown
+	// wave.
+	Wave int `json:"wave"`
+	// SourceFile is the path of the configuration file that defines the
+	// app, it is empty if it is unknown.
+	SourceFile string `json:"source_file"`
+}
+
+// GraphDocumentEdge is a dependency in a GraphDocument.
+type GraphDocumentEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Type is either cfg.TypeHardDependency or cfg.TypeSoftDependency.
+	Type string `json:"type"`
+}
+
+// GraphDocument returns the dependency graph of the distribution.
+// If apps is not empty, the graph only contains the given app names and their
+// recursive dependencies.
+// If a loop exist between hard dependencies a *CycleError is returned.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) GraphDocument(distribution string, apps ...string) (*GraphDocument, error) {
+	waves, err := c.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.graphDocument(distribution, waves, false, apps)
+}
+
+// TeardownGraphDocument returns the dependency graph of the distribution
+// like GraphDocument, the waves of the nodes are teardown waves, as
+// returned by TeardownWaves.
+func (c *Composition) TeardownGraphDocument(distribution string, apps ...string) (*GraphDocument, error) {
+	waves, err := c.TeardownWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.graphDocument(distribution, waves, true, apps)
+}
+
+func (c *Composition) graphDocument(distribution string, waves [][]string, teardown bool, apps []string) (*GraphDocument, error) {
+	edges, err := c.Edges(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := GraphDocument{
+		Version:      GraphDocumentVersion,
+		Distribution: distribution,
+		Teardown:     teardown,
+		Nodes:        []*GraphDocumentNode{},
+		Edges:        make([]*GraphDocumentEdge, 0, len(edges)),
+	}
+
+	for i, wave := range waves {
+		for _, app := range wave {
+			doc.Nodes = append(doc.Nodes, &GraphDocumentNode{
+				Name:       app,
+				Wave:       i + 1,
+				SourceFile: c.Distribution[distribution][app].SourceFile,
+			})
+		}
+	}
+
+	for _, e := range edges {
+		doc.Edges = append(doc.Edges, &GraphDocumentEdge{From: e.From, To: e.To, Type: e.Type})
+	}
+
+	return &doc, nil
+}
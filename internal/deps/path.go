@@ -0,0 +1,203 @@
+package deps
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// DependencyPath is a chain of dependencies from one app to another.
+type DependencyPath []*Edge
+
+// String returns the path in the format "a -> b -> c".
+func (p DependencyPath) String() string {
+	return Cycle(p).String()
+}
+
+// DependencyPaths returns the dependency paths from the app from to the app
+// to, in the distribution.
+// A path does not contain an app more than once.
+// If shortestOnly is true, only the paths with the lowest number of edges
+// are returned.
+// If maxPaths is greater than 0, at most maxPaths paths are returned, they
+// are the first maxPaths paths of the sort order.
+// The paths are sorted by their length and the names of their apps.
+// If from or to is not part of the distribution an error is returned.
+func (c *Composition) DependencyPaths(distribution, from, to string, shortestOnly bool, maxPaths int) ([]DependencyPath, error) {
+	if _, exists := c.Distribution[distribution][to]; !exists {
+		return nil, fmt.Errorf("the app does not exist: %s", to)
+	}
+
+	adjacency, err := c.reachableEdges(distribution, from)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == to {
+		return nil, nil
+	}
+
+	// distTo contains the apps that can reach the app to, with the
+	// minimal number of edges to it. Edges to other apps are never part
+	// of a path.
+	distTo := distancesTo(adjacency, to)
+	shortest, exists := distTo[from]
+	if !exists {
+		return nil, nil
+	}
+
+	maxLen := len(distTo) - 1
+	if shortestOnly {
+		maxLen = shortest
+	}
+
+	var res []DependencyPath
+	var path DependencyPath
+	onPath := map[string]struct{}{from: {}}
+
+	// walk appends all paths with exactly length edges in lexical order
+	// of their app names to res. The edges of adjacency are sorted, the
+	// paths are therefore found in that order.
+	var walk func(app string, length int) bool
+	walk = func(app string, length int) bool {
+		for _, e := range adjacency[app] {
+			dist, reachesTo := distTo[e.To]
+			if !reachesTo || len(path)+1+dist > length {
+				continue
+			}
+			if _, exists := onPath[e.To]; exists {
+				continue
+			}
+
+			path = append(path, e)
+			if e.To == to {
+				if len(path) == length {
+					res = append(res, slices.Clone(path))
+					if maxPaths > 0 && len(res) >= maxPaths {
+						return false
+					}
+				}
+			} else {
+				onPath[e.To] = struct{}{}
+				if !walk(e.To, length) {
+					return false
+				}
+				delete(onPath, e.To)
+			}
+			path = path[:len(path)-1]
+		}
+
+		return true
+	}
+
+	// the paths are searched with increasing length, to find the
+	// shortest first
+	for length := shortest; length <= maxLen; length++ {
+		if !walk(from, length) {
+			break
+		}
+	}
+
+	return res, nil
+}
+
+// DependencyPathsDot returns the dependency graph of the app from in the dot
+// format, like DependencyOrderDot does. The edges and apps of paths are
+// highlighted.
+func (c *Composition) DependencyPathsDot(distribution, from string, paths []DependencyPath) (string, error) {
+	edges, err := c.Edges(distribution, from)
+	if err != nil {
+		return "", err
+	}
+
+	onPath := map[graphs.Edge]struct{}{}
+	for _, p := range paths {
+		for _, e := range p {
+			onPath[graphs.Edge{Start: e.From, End: e.To}] = struct{}{}
+		}
+	}
+
+	graph := graphs.NewDotDiGraph()
+	if err := graph.AddNode(from); err != nil {
+		return "", fmt.Errorf("could not add node %v to graph: %w", from, err)
+	}
+
+	for _, e := range edges {
+		for _, n := range []string{e.From, e.To} {
+			if err := graph.AddNode(n); err != nil {
+				return "", fmt.Errorf("could not add node %v to graph: %w", n, err)
+			}
+		}
+
+		dotted := e.Type == cfg.TypeSoftDependency
+		if _, exists := onPath[graphs.Edge{Start: e.From, End: e.To}]; exists {
+			for _, n := range []string{e.From, e.To} {
+				if err := graph.HighlightNode(n); err != nil {
+					return "", fmt.Errorf("could not highlight node %v: %w", n, err)
+				}
+			}
+			err = graph.AddHighlightedEdge(e.From, e.To, dotted)
+		} else if dotted {
+			err = graph.AddDottedEdge(e.From, e.To)
+		} else {
+			err = graph.AddEdge(e.From, e.To)
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not add edge from %v to %v: %w", e.From, e.To, err)
+		}
+	}
+
+	return graph.String(), nil
+}
+
+// reachableEdges returns the edges of app and its recursive dependencies, as a
+// map of map[APP-NAME][]EDGE. The edges of an app are sorted.
+func (c *Composition) reachableEdges(distribution, app string) (map[string][]*Edge, error) {
+	edges, err := c.Edges(distribution, app)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]*Edge{}
+	for _, e := range edges {
+		res[e.From] = append(res[e.From], e)
+	}
+
+	return res, nil
+}
+
+// distancesTo returns the minimal number of edges from the apps of
+// adjacency that can reach the app to, to it.
+func distancesTo(adjacency map[string][]*Edge, to string) map[string]int {
+	reverse := map[string][]string{}
+	for _, edges := range adjacency {
+		for _, e := range edges {
+			reverse[e.To] = append(reverse[e.To], e.From)
+		}
+	}
+
+	return bfsDistances(to, func(app string) []string {
+		return reverse[app]
+	})
+}
+
+// bfsDistances returns the distances of all vertices that are reachable from
+// start, neighbors returns the adjacent vertices of a vertex.
+func bfsDistances(start string, neighbors func(string) []string) map[string]int {
+	dist := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range neighbors(v) {
+			if _, visited := dist[w]; visited {
+				continue
+			}
+			dist[w] = dist[v] + 1
+			queue = append(queue, w)
+		}
+	}
+	return dist
+}
@@ -0,0 +1,137 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// PlanVersion is the version of the Plan format.
+const PlanVersion = 1
+
+// Plan is a serializable deployment plan for a distribution.
+type Plan struct {
+	Version      int    `json:"version"`
+	Distribution string `json:"distribution"`
+	// Apps are the apps the plan was created for, if empty it was created
+	// for all apps of the distribution.
+	Apps []string `json:"apps,omitempty"`
+	// Waves are the deployment waves, as returned by
+	// Composition.DependencyWaves.
+	Waves [][]string `json:"waves"`
+	// CompositionHash is the hash of the composition the plan was created
+	// from, as returned by Composition.Hash.
+	CompositionHash string `json:"composition_hash"`
+}
+
+// Plan creates a deployment plan for the distribution.
+// If apps is not empty, the plan only contains the given app names and their
+// dependencies instead of all.
+func (c *Composition) Plan(distribution string, apps ...string) (*Plan, error) {
+	waves, err := c.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := c.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		Version:         PlanVersion,
+		Distribution:    distribution,
+		Apps:            apps,
+		Waves:           waves,
+		CompositionHash: hash,
+	}, nil
+}
+
+// Hash returns a hex-encoded SHA256 hash of the plan.
+// Plans with the same distribution, apps, waves and composition hash have
+// the same hash.
+func (p *Plan) Hash() (string, error) {
+	// json.Marshal encodes struct fields in declaration order, the encoding
+	// is deterministic
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Hash returns a hex-encoded SHA256 hash of the dependencies in the
+// composition.
+// Only the apps and their dependencies are hashed, the order of the
+// dependencies, the source files and the deploy durations do not change the
+// hash.
+func (c *Composition) Hash() (string, error) {
+	type normalizedDeps struct {
+		SoftDeps []string `json:"soft_dependencies"`
+		HardDeps []string `json:"hard_dependencies"`
+	}
+
+	normalized := make(map[string]map[string]*normalizedDeps, len(c.Distribution))
+	for distrName, distr := range c.Distribution {
+		apps := make(map[string]*normalizedDeps, len(distr))
+		for appName, d := range distr {
+			nd := normalizedDeps{
+				SoftDeps: slices.Clone(d.SoftDeps),
+				HardDeps: slices.Clone(d.HardDeps),
+			}
+			slices.Sort(nd.SoftDeps)
+			slices.Sort(nd.HardDeps)
+			apps[appName] = &nd
+		}
+		normalized[distrName] = apps
+	}
+
+	// json.Marshal sorts map keys, the encoding is deterministic
+	buf, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// PlanFromFile loads a plan from the JSON file path.
+func PlanFromFile(path string) (*Plan, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan Plan
+	if err := json.Unmarshal(buf, &plan); err != nil {
+		return nil, err
+	}
+
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expecting %d", plan.Version, PlanVersion)
+	}
+
+	return &plan, nil
+}
+
+// VerifyPlan returns an error if plan was not created from a composition
+// with the same dependencies as c.
+func (c *Composition) VerifyPlan(plan *Plan) error {
+	hash, err := c.Hash()
+	if err != nil {
+		return err
+	}
+
+	if hash != plan.CompositionHash {
+		return fmt.Errorf("composition changed since the plan was created, composition hash is %s, plan was created for %s",
+			hash, plan.CompositionHash)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package deps
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// hardDependencyGraph returns a graph of the distribution, that only contains
+// the hard dependencies between the apps.
+func (c *Composition) hardDependencyGraph(distribution string) (*graphs.Graph, error) {
+	distrDeps := c.Distribution[distribution]
+	if distrDeps == nil {
+		return nil, errors.New("no apps are defined for the distribution")
+	}
+
+	g := graphs.NewDigraph()
+	for appName, deps := range distrDeps {
+		g.AddVertex(appName)
+		for _, hd := range deps.HardDeps {
+			g.AddEdge(appName, hd)
+		}
+	}
+
+	return g, nil
+}
+
+// RedundantHardDependencies returns the hard dependencies of the
+// distribution, that are already implied transitively by other hard
+// dependencies. For example a dependency from a to c is redundant, if a
+// depends on b and b depends on c.
+// If a loop exist between hard dependencies a *CycleError is returned.
+func (c *Composition) RedundantHardDependencies(distribution string) ([]*Edge, error) {
+	g, err := c.hardDependencyGraph(distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	redundant, err := graphs.RedundantEdges(g)
+	if err != nil {
+		if errors.Is(err, graphs.ErrNoDAG) {
+			return nil, c.newCycleError(distribution, g)
+		}
+		return nil, err
+	}
+
+	res := make([]*Edge, 0, len(redundant))
+	for _, e := range redundant {
+		res = append(res, c.edge(distribution, e.Start, e.End, cfg.TypeHardDependency))
+	}
+
+	return res, nil
+}
+
+// TransitiveReduction returns a new Composition that only contains the given
+// distribution, without the hard dependencies that are returned by
+// RedundantHardDependencies.
+func (c *Composition) TransitiveReduction(distribution string) (*Composition, error) {
+	redundant, err := c.RedundantHardDependencies(distribution)
+	if err != nil {
+		return nil, err
+	}
+
+	isRedundant := make(map[graphs.Edge]struct{}, len(redundant))
+	for _, e := range redundant {
+		isRedundant[graphs.Edge{Start: e.From, End: e.To}] = struct{}{}
+	}
+
+	res := NewComposition()
+	res.Distribution[distribution] = map[string]*Dependencies{}
+	for appName, deps := range c.Distribution[distribution] {
+		reduced := *deps
+		reduced.HardDeps = slices.DeleteFunc(slices.Clone(deps.HardDeps), func(hd string) bool {
+			_, exists := isRedundant[graphs.Edge{Start: appName, End: hd}]
+			return exists
+		})
+		res.Add(distribution, appName, &reduced)
+	}
+
+	return res, nil
+}
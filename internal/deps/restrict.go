@@ -0,0 +1,182 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
+)
+
+// Restrict returns a new Composition that only contains the given apps of
+// the distribution.
+// Dependencies on apps that are not part of apps are removed. To preserve
+// the dependency order, a hard dependency is added between two apps if the
+// first app depends on the second one via a path of hard dependencies that
+// only passes removed apps.
+// If an app name is not part of the distribution an error is returned.
+func (c *Composition) Restrict(distribution string, apps []string) (*Composition, error) {
+	distrDeps := c.Distribution[distribution]
+	if distrDeps == nil {
+		return nil, errors.New("no apps are defined for the distribution")
+	}
+
+	included := datastructs.SliceToSet(apps)
+	for app := range included {
+		if _, exists := distrDeps[app]; !exists {
+			return nil, fmt.Errorf("the app does not exist: %s", app)
+		}
+	}
+
+	res := NewComposition()
+	res.Distribution[distribution] = map[string]*Dependencies{}
+
+	for app := range included {
+		deps := *distrDeps[app]
+		deps.HardDeps = c.reachableHardDeps(distribution, app, included)
+		deps.SoftDeps = nil
+		for _, sd := range distrDeps[app].SoftDeps {
+			if _, exists := included[sd]; exists {
+				deps.SoftDeps = append(deps.SoftDeps, sd)
+			}
+		}
+
+		res.Add(distribution, app, &deps)
+	}
+
+	return res, nil
+}
+
+// reachableHardDeps returns the elements of included that app depends on via
+// a path of hard dependencies, that does not pass other elements of
+// included.
+func (c *Composition) reachableHardDeps(distribution, app string, included map[string]struct{}) []string {
+	var res []string
+
+	distrDeps := c.Distribution[distribution]
+	visited := map[string]struct{}{}
+	stack := slices.Clone(distrDeps[app].HardDeps)
+
+	for len(stack) > 0 {
+		dep := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if _, exists := visited[dep]; exists {
+			continue
+		}
+		visited[dep] = struct{}{}
+
+		if _, exists := included[dep]; exists {
+			res = append(res, dep)
+			continue
+		}
+
+		if deps := distrDeps[dep]; deps != nil {
+			stack = append(stack, deps.HardDeps...)
+		}
+	}
+
+	slices.Sort(res)
+
+	return res
+}
+
+// WithDependencies returns apps and all of their recursive dependencies,
+// sorted by name.
+// If an app name is not part of the distribution an error is returned.
+func (c *Composition) WithDependencies(distribution string, apps []string) ([]string, error) {
+	var res []string
+
+	if len(apps) == 0 {
+		return nil, nil
+	}
+
+	err := c.forEachRecursive(distribution, apps, func(appName string, _ *Dependencies) error {
+		res = append(res, appName)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(res)
+
+	return res, nil
+}
+
+// WithDependents returns apps and all apps that depend directly or
+// transitively on them, sorted by name.
+// If an app name is not part of the distribution an error is returned.
+func (c *Composition) WithDependents(distribution string, apps []string) ([]string, error) {
+	set := datastructs.SliceToSet(apps)
+	for _, app := range apps {
+		dependents, err := c.Dependents(distribution, app, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dependents {
+			set[d.Name] = struct{}{}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(set)), nil
+}
+
+// OwningApps maps paths to the apps of the distribution that own them.
+// An app owns all files in the directory that contains its configuration
+// file, cfgPath is the path suffix of the configuration files.
+// If the directory of an app is a sub-directory of another app's directory,
+// the files in it are owned by the app with the closest directory.
+// The paths must be relative to the root directory, the composition was
+// loaded from. Apps without a known SourceFile do not own any paths.
+// OwningApps returns the sorted names of the owning apps and all paths that
+// are not owned by any app.
+func (c *Composition) OwningApps(distribution, cfgPath string, paths []string) (owners []string, unowned []string, err error) {
+	distrDeps := c.Distribution[distribution]
+	if distrDeps == nil {
+		return nil, nil, errors.New("no apps are defined for the distribution")
+	}
+
+	// the directory of an app is the directory in that contains cfgPath,
+	// cfgPath can consist of multiple path elements
+	cfgPathDepth := len(strings.Split(filepath.Clean(cfgPath), string(filepath.Separator)))
+	appDirs := map[string]string{}
+	for app, deps := range distrDeps {
+		if deps.SourceFile == "" {
+			continue
+		}
+
+		dir := filepath.Clean(deps.SourceFile)
+		for range cfgPathDepth {
+			dir = filepath.Dir(dir)
+		}
+		appDirs[dir] = app
+	}
+
+	ownerSet := map[string]struct{}{}
+	for _, p := range paths {
+		app, exists := closestOwner(appDirs, filepath.Clean(p))
+		if !exists {
+			unowned = append(unowned, p)
+			continue
+		}
+		ownerSet[app] = struct{}{}
+	}
+
+	return slices.Sorted(maps.Keys(ownerSet)), unowned, nil
+}
+
+func closestOwner(appDirs map[string]string, path string) (string, bool) {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if app, exists := appDirs[dir]; exists {
+			return app, true
+		}
+
+		if dir == "." || dir == string(filepath.Separator) || filepath.Dir(dir) == dir {
+			return "", false
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/graphs"
+)
+
+// SoftDependencyClusters returns the groups of apps of the distribution that
+// depend on each other via soft dependency loops.
+// They are the strongly connected components of the soft dependency graph,
+// that contain more than 1 app. Apps of a cluster must be deployed together.
+// The apps of a cluster are sorted by name, the clusters are sorted by their
+// first app.
+// If apps is not empty, only the clusters of the given apps and their
+// recursive dependencies are returned.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) SoftDependencyClusters(distribution string, apps ...string) ([][]string, error) {
+	g := graphs.NewDigraph()
+
+	err := c.forEach(distribution, apps,
+		func(appName string, deps *Dependencies) error {
+			g.AddVertex(appName)
+			for _, sd := range deps.SoftDeps {
+				g.AddEdge(appName, sd)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]string
+	for _, component := range graphs.StronglyConnectedComponents(g) {
+		if len(component) > 1 {
+			res = append(res, component)
+		}
+	}
+
+	return res, nil
+}
+
+// DependencyOrderGrouped calculates the dependency order like
+// DependencyOrder, but places the apps of each soft dependency cluster, as
+// returned by SoftDependencyClusters, together as one unit.
+// Each element of the returned slice is a deployment unit. It either
+// contains a single app or the apps of a cluster. The apps of a cluster are
+// ordered by their hard dependencies.
+// If a loop exist between hard dependencies a *CycleError is returned.
+// If apps of a cluster can not be deployed as unit, because another app has
+// a hard dependency on an app of the cluster and itself is a hard dependency of
+// another app of the cluster, an error is returned.
+// If apps is not empty, the order is only calculated for the given app names
+// and their dependencies instead of all.
+// If an app name is not part of the distribution and error is returned.
+func (c *Composition) DependencyOrderGrouped(distribution string, apps ...string) ([][]string, error) {
+	order, err := c.DependencyOrder(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := c.SoftDependencyClusters(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	// units maps the app names to the name of their deployment unit,
+	// apps that are not part of a cluster are their own unit
+	units := make(map[string]string, len(order))
+	members := make(map[string][]string, len(order))
+	for _, app := range order {
+		units[app] = app
+	}
+	for i, cluster := range clusters {
+		unit := rootVertexName + "-cluster-" + strconv.Itoa(i)
+		for _, app := range cluster {
+			units[app] = unit
+		}
+	}
+	// order contains the apps in dependency order, the members of a
+	// cluster are added in the same order
+	for _, app := range order {
+		members[units[app]] = append(members[units[app]], app)
+	}
+
+	g := graphs.NewDigraph()
+	g.AddVertex(rootVertexName)
+	distrDeps := c.Distribution[distribution]
+	for _, app := range order {
+		unit := units[app]
+		g.AddEdge(rootVertexName, unit)
+		for _, hd := range distrDeps[app].HardDeps {
+			if units[hd] != unit {
+				g.AddEdge(unit, units[hd])
+			}
+		}
+	}
+
+	sorted, _, err := graphs.TopologicalSort(g)
+	if err != nil {
+		if errors.Is(err, graphs.ErrNoDAG) {
+			return nil, unitCycleError(g, members)
+		}
+		return nil, err
+	}
+
+	unitOrder := datastructs.ListToSlice(sorted)[1:]
+	slices.Reverse(unitOrder)
+
+	res := make([][]string, 0, len(unitOrder))
+	for _, unit := range unitOrder {
+		res = append(res, members[unit])
+	}
+
+	return res, nil
+}
+
+// DependencyOrderDotGrouped returns the same graph as DependencyOrderDot,
+// additionally the apps of each soft dependency cluster, as returned by
+// SoftDependencyClusters, are grouped in a subgraph named cluster_<N>.
+func (c *Composition) DependencyOrderDotGrouped(distribution string, apps ...string) (string, error) {
+	graph, err := c.dependencyOrderDot(distribution, apps)
+	if err != nil {
+		return "", err
+	}
+
+	clusters, err := c.SoftDependencyClusters(distribution, apps...)
+	if err != nil {
+		return "", err
+	}
+
+	for i, cluster := range clusters {
+		if err := graph.AddCluster(strconv.Itoa(i), cluster); err != nil {
+			return "", fmt.Errorf("could not add cluster %v to graph: %w", cluster, err)
+		}
+	}
+
+	return graph.String(), nil
+}
+
+// unitCycleError returns an error describing a loop between deployment
+// units in g.
+func unitCycleError(g *graphs.Graph, members map[string][]string) error {
+	var loops []string
+	for _, component := range graphs.StronglyConnectedComponents(g) {
+		cycle := graphs.FindCycle(g, component)
+		if len(cycle) == 0 {
+			continue
+		}
+
+		cycle = append(cycle, cycle[0])
+		names := make([]string, 0, len(cycle))
+		for _, unit := range cycle {
+			if len(members[unit]) == 1 {
+				names = append(names, members[unit][0])
+				continue
+			}
+			names = append(names, "{"+strings.Join(members[unit], ", ")+"}")
+		}
+		loops = append(loops, strings.Join(names, " -> "))
+	}
+
+	return fmt.Errorf("soft dependency clusters can not be deployed as one unit, they are part of hard dependency loops: %s",
+		strings.Join(loops, "; "))
+}
@@ -45,3 +45,11 @@ func Find(rootdir string, matchSuffix string, ignoredDirs []string) ([]string, e
 
 	return res, nil
 }
+
+// IsFileName returns true if name can be used as the name of a file in a
+// directory. name must be a local path that consists of a single element
+// other than ".", path separators of all operating systems and line breaks
+// are not allowed.
+func IsFileName(name string) bool {
+	return filepath.IsLocal(name) && name != "." && !strings.ContainsAny(name, "/\\\n")
+}
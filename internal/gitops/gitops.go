@@ -0,0 +1,189 @@
+// Package gitops generates the deployment order of apps for GitOps tools.
+package gitops
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"text/template"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+// Supported tools.
+const (
+	ToolArgoCD = "argocd"
+	ToolFlux   = "flux"
+)
+
+// Tools are the supported tools.
+var Tools = []string{ToolArgoCD, ToolFlux}
+
+// ArgoCDSyncWaveAnnotation is the annotation that defines the sync wave of
+// an Argo CD resource.
+const ArgoCDSyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+
+// NameData are the fields that are available in the resource name template.
+type NameData struct {
+	App          string
+	Distribution string
+}
+
+// Metadata is the metadata of a Kubernetes resource.
+type Metadata struct {
+	Name        string            `yaml:"name" json:"name"`
+	Namespace   string            `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// Resource is a patch of a Kubernetes resource.
+type Resource struct {
+	APIVersion string    `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string    `yaml:"kind" json:"kind"`
+	Metadata   Metadata  `yaml:"metadata" json:"metadata"`
+	Spec       *FluxSpec `yaml:"spec,omitempty" json:"spec,omitempty"`
+}
+
+// FluxSpec is the part of the spec of a Flux Kustomization, that defines
+// its dependencies.
+type FluxSpec struct {
+	DependsOn []*FluxDependency `yaml:"dependsOn" json:"dependsOn"`
+}
+
+// FluxDependency is a reference to a Kustomization another one depends on.
+type FluxDependency struct {
+	Name      string `yaml:"name" json:"name"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+// Generator creates resource patches for the apps of a distribution.
+type Generator struct {
+	// Name is rendered for every app to get the name of its resource.
+	Name *template.Template
+	// Namespace of the resources, can be empty.
+	Namespace string
+}
+
+// ArgoCDSyncWaves returns the sync wave of every app of the distribution.
+// The waves are the deployment waves, as returned by
+// Composition.DependencyWaves, the first wave is 0.
+// If apps is not empty, only the sync waves of the given apps and their
+// dependencies are returned.
+func ArgoCDSyncWaves(composition *deps.Composition, distribution string, apps ...string) (map[string]int, error) {
+	waves, err := composition.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]int{}
+	for i, wave := range waves {
+		for _, app := range wave {
+			res[app] = i
+		}
+	}
+
+	return res, nil
+}
+
+// FluxDependsOn returns the hard dependencies of every app of the
+// distribution, sorted by name.
+// If apps is not empty, only the dependencies of the given apps and their
+// dependencies are returned.
+// If a loop exist between hard dependencies a *CycleError is returned.
+func FluxDependsOn(composition *deps.Composition, distribution string, apps ...string) (map[string][]string, error) {
+	// DependencyWaves ensures that the dependencies are acyclic, Flux
+	// would never reconcile Kustomizations that depend on each other
+	waves, err := composition.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]string{}
+	for _, wave := range waves {
+		for _, app := range wave {
+			hardDeps := append([]string{}, composition.Distribution[distribution][app].HardDeps...)
+			slices.Sort(hardDeps)
+			res[app] = hardDeps
+		}
+	}
+
+	return res, nil
+}
+
+// ArgoCDPatches returns for every app an Argo CD Application patch, that
+// sets the sync wave annotation.
+// An error is returned if the resource names of multiple apps are equal.
+func (g *Generator) ArgoCDPatches(distribution string, syncWaves map[string]int) (map[string]*Resource, error) {
+	res := make(map[string]*Resource, len(syncWaves))
+	for app, wave := range syncWaves {
+		meta, err := g.metadata(distribution, app)
+		if err != nil {
+			return nil, err
+		}
+		meta.Annotations = map[string]string{ArgoCDSyncWaveAnnotation: fmt.Sprint(wave)}
+
+		res[app] = &Resource{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Application",
+			Metadata:   *meta,
+		}
+	}
+
+	return res, checkUniqueNames(res)
+}
+
+// FluxPatches returns for every app a Flux Kustomization patch, that sets
+// spec.dependsOn.
+// An error is returned if the resource names of multiple apps are equal.
+func (g *Generator) FluxPatches(distribution string, dependsOn map[string][]string) (map[string]*Resource, error) {
+	res := make(map[string]*Resource, len(dependsOn))
+	for app, appDeps := range dependsOn {
+		meta, err := g.metadata(distribution, app)
+		if err != nil {
+			return nil, err
+		}
+
+		spec := FluxSpec{DependsOn: []*FluxDependency{}}
+		for _, dep := range appDeps {
+			depMeta, err := g.metadata(distribution, dep)
+			if err != nil {
+				return nil, err
+			}
+			spec.DependsOn = append(spec.DependsOn, &FluxDependency{Name: depMeta.Name, Namespace: depMeta.Namespace})
+		}
+
+		res[app] = &Resource{
+			APIVersion: "kustomize.toolkit.fluxcd.io/v1",
+			Kind:       "Kustomization",
+			Metadata:   *meta,
+			Spec:       &spec,
+		}
+	}
+
+	return res, checkUniqueNames(res)
+}
+
+// checkUniqueNames returns an error if the resources of multiple apps have
+// the same name, their patches would overwrite each other.
+func checkUniqueNames(resources map[string]*Resource) error {
+	seen := map[string]string{}
+	for _, app := range slices.Sorted(maps.Keys(resources)) {
+		name := resources[app].Metadata.Name
+		if other, exists := seen[name]; exists {
+			return fmt.Errorf("the apps %s and %s have the same resource name %q", other, app, name)
+		}
+		seen[name] = app
+	}
+
+	return nil
+}
+
+func (g *Generator) metadata(distribution, app string) (*Metadata, error) {
+	var buf bytes.Buffer
+	if err := g.Name.Execute(&buf, &NameData{App: app, Distribution: distribution}); err != nil {
+		return nil, fmt.Errorf("rendering resource name of %s failed: %w", app, err)
+	}
+
+	return &Metadata{Name: buf.String(), Namespace: g.Namespace}, nil
+}
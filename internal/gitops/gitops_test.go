@@ -0,0 +1,69 @@
+package gitops
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+func testComposition() *deps.Composition {
+	comp := deps.NewComposition()
+	comp.Add("prd", "api", &deps.Dependencies{HardDeps: []string{"db", "auth"}, SoftDeps: []string{"cache"}})
+	comp.Add("prd", "auth", &deps.Dependencies{HardDeps: []string{"db"}})
+	comp.Add("prd", "db", &deps.Dependencies{})
+	comp.Add("prd", "cache", &deps.Dependencies{})
+	return comp
+}
+
+func TestArgoCDPatches(t *testing.T) {
+	waves, err := ArgoCDSyncWaves(testComposition(), "prd")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"db": 0, "cache": 0, "auth": 1, "api": 2}, waves)
+
+	gen := Generator{Name: template.Must(template.New("").Parse("{{.Distribution}}-{{.App}}")), Namespace: "argocd"}
+	patches, err := gen.ArgoCDPatches("prd", waves)
+	require.NoError(t, err)
+	assert.Equal(t, &Resource{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: Metadata{
+			Name:        "prd-auth",
+			Namespace:   "argocd",
+			Annotations: map[string]string{ArgoCDSyncWaveAnnotation: "1"},
+		},
+	}, patches["auth"])
+}
+
+func TestFluxPatches(t *testing.T) {
+	dependsOn, err := FluxDependsOn(testComposition(), "prd", "api")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"api":   {"auth", "db"},
+		"auth":  {"db"},
+		"db":    {},
+		"cache": {},
+	}, dependsOn)
+
+	gen := Generator{Name: template.Must(template.New("").Parse("{{.App}}"))}
+	patches, err := gen.FluxPatches("prd", dependsOn)
+	require.NoError(t, err)
+	assert.Equal(t, &FluxSpec{DependsOn: []*FluxDependency{{Name: "auth"}, {Name: "db"}}}, patches["api"].Spec)
+}
+
+func TestPatchesWithSameResourceName(t *testing.T) {
+	waves, err := ArgoCDSyncWaves(testComposition(), "prd")
+	require.NoError(t, err)
+
+	gen := Generator{Name: template.Must(template.New("").Parse("{{.Distribution}}"))}
+	_, err = gen.ArgoCDPatches("prd", waves)
+	assert.ErrorContains(t, err, `the apps api and auth have the same resource name "prd"`)
+
+	dependsOn, err := FluxDependsOn(testComposition(), "prd")
+	require.NoError(t, err)
+	_, err = gen.FluxPatches("prd", dependsOn)
+	assert.ErrorContains(t, err, "have the same resource name")
+}
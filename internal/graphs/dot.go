@@ -42,3 +42,36 @@ func (g *Dot) AddDottedEdge(src, dest string) error {
 func (g *Dot) String() string {
 	return g.g.String()
 }
+
+// AddCluster adds a subgraph named cluster_<name> that contains the given
+// nodes. Graphviz draws the nodes of a cluster together in a box.
+func (g *Dot) AddCluster(name string, nodes []string) error {
+	sgName := "cluster_" + name
+	if err := g.g.AddSubGraph(g.graphName, sgName, map[string]string{"style": "dashed"}); err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if err := g.g.AddNode(sgName, n, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddHighlightedEdge adds an edge that is drawn bold and red.
+// If dotted is true, the edge is drawn dotted.
+func (g *Dot) AddHighlightedEdge(src, dest string, dotted bool) error {
+	attrs := map[string]string{"color": "red", "penwidth": "2"}
+	if dotted {
+		attrs["style"] = "dotted"
+	}
+	return g.g.AddEdge(src, dest, true, attrs)
+}
+
+// HighlightNode draws the node in red. If the node does not exist, it is
+// added.
+func (g *Dot) HighlightNode(name string) error {
+	return g.g.AddNode(g.graphName, name, map[string]string{"color": "red"})
+}
@@ -0,0 +1,81 @@
+package graphs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// GraphML is a directed graph in the GraphML format.
+// The names of nodes are stored in the "label" attribute, the kind of edges
+// in the "type" attribute.
+// Nodes and edges are written sorted by name, independent of the order in
+// which they were added.
+type GraphML struct {
+	edgeSet
+	graphAttrs map[string]string
+	labels     EdgeLabels
+}
+
+// NewGraphML returns an empty GraphML graph. graphAttrs are written as
+// attributes of the graph element.
+func NewGraphML(graphAttrs map[string]string, labels EdgeLabels) *GraphML {
+	return &GraphML{
+		edgeSet:    newEdgeSet(),
+		graphAttrs: graphAttrs,
+		labels:     labels,
+	}
+}
+
+func (g *GraphML) String() string {
+	var sb strings.Builder
+	nodes := g.sortedNodes()
+	attrNames := slices.Sorted(maps.Keys(g.graphAttrs))
+	attrKeys := uniqueIDs(attrNames, func(n string) string { return sanitizeID("g_", n) })
+
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns"` +
+		` xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"` +
+		` xsi:schemaLocation="http://graphml.graphdrawing.org/xmlns http://graphml.graphdrawing.org/xmlns/1.0/graphml.xsd">` + "\n")
+	for _, name := range attrNames {
+		fmt.Fprintf(&sb, "  <key id=\"%s\" for=\"graph\" attr.name=\"%s\" attr.type=\"string\"/>\n",
+			attrKeys[name], xmlEscape(name))
+	}
+	sb.WriteString("  <key id=\"label\" for=\"node\" attr.name=\"label\" attr.type=\"string\"/>\n")
+	sb.WriteString("  <key id=\"type\" for=\"edge\" attr.name=\"type\" attr.type=\"string\"/>\n")
+
+	sb.WriteString("  <graph id=\"G\" edgedefault=\"directed\">\n")
+	for _, name := range attrNames {
+		fmt.Fprintf(&sb, "    <data key=\"%s\">%s</data>\n", attrKeys[name], xmlEscape(g.graphAttrs[name]))
+	}
+
+	for _, n := range nodes {
+		fmt.Fprintf(&sb, "    <node id=\"%s\">\n", xmlEscape(n))
+		fmt.Fprintf(&sb, "      <data key=\"label\">%s</data>\n", xmlEscape(n))
+		sb.WriteString("    </node>\n")
+	}
+
+	for i, e := range g.sortedEdges() {
+		label := g.labels.Edge
+		if e.dotted {
+			label = g.labels.DottedEdge
+		}
+
+		fmt.Fprintf(&sb, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.src), xmlEscape(e.dest))
+		fmt.Fprintf(&sb, "      <data key=\"type\">%s</data>\n", xmlEscape(label))
+		sb.WriteString("    </edge>\n")
+	}
+
+	sb.WriteString("  </graph>\n")
+	sb.WriteString("</graphml>\n")
+
+	return sb.String()
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s)) // writing to a strings.Builder never fails
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package graphs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mermaid is a Mermaid flowchart.
+// Nodes and edges are written sorted by name, independent of the order in
+// which they were added.
+type Mermaid struct {
+	edgeSet
+}
+
+func NewMermaidFlowchart() *Mermaid {
+	return &Mermaid{edgeSet: newEdgeSet()}
+}
+
+func (g *Mermaid) String() string {
+	var sb strings.Builder
+	nodes := g.sortedNodes()
+	// the prefix prevents clashes with keywords like "end" and IDs
+	// starting with characters that have a special meaning
+	ids := uniqueIDs(nodes, func(n string) string { return sanitizeID("n_", n) })
+
+	sb.WriteString("flowchart TD\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&sb, "    %s[\"%s\"]\n", ids[n], mermaidEscape(n))
+	}
+
+	for _, e := range g.sortedEdges() {
+		arrow := "-->"
+		if e.dotted {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&sb, "    %s %s %s\n", ids[e.src], arrow, ids[e.dest])
+	}
+
+	return sb.String()
+}
+
+// mermaidEscape escapes characters that can not be used in a quoted node
+// label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,62 @@
+package graphs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlantUML is a PlantUML component diagram.
+// Nodes are written as components, edges are labeled with their kind.
+// Nodes and edges are written sorted by name, independent of the order in
+// which they were added.
+type PlantUML struct {
+	edgeSet
+	title  string
+	labels EdgeLabels
+}
+
+// NewPlantUML returns an empty PlantUML diagram. If title is not empty, it
+// is written as title of the diagram.
+func NewPlantUML(title string, labels EdgeLabels) *PlantUML {
+	return &PlantUML{
+		edgeSet: newEdgeSet(),
+		title:   title,
+		labels:  labels,
+	}
+}
+
+func (g *PlantUML) String() string {
+	var sb strings.Builder
+	nodes := g.sortedNodes()
+	ids := uniqueIDs(nodes, func(n string) string { return sanitizeID("n_", n) })
+
+	sb.WriteString("@startuml\n")
+	if g.title != "" {
+		fmt.Fprintf(&sb, "title %s\n", g.title)
+	}
+
+	for _, n := range nodes {
+		fmt.Fprintf(&sb, "component \"%s\" as %s\n", plantUMLEscape(n), ids[n])
+	}
+
+	for _, e := range g.sortedEdges() {
+		arrow, label := "-->", g.labels.Edge
+		if e.dotted {
+			arrow, label = "..>", g.labels.DottedEdge
+		}
+
+		if label == "" {
+			fmt.Fprintf(&sb, "%s %s %s\n", ids[e.src], arrow, ids[e.dest])
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %s %s : %s\n", ids[e.src], arrow, ids[e.dest], label)
+	}
+
+	sb.WriteString("@enduml\n")
+
+	return sb.String()
+}
+
+func plantUMLEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "<U+0022>")
+}
@@ -0,0 +1,155 @@
+package graphs
+
+import (
+	"slices"
+)
+
+// sortedNeighbors returns the end vertices of all edges that start at v,
+// sorted by name.
+func (g *Graph) sortedNeighbors(v string) []string {
+	var res []string
+	for he := range g.HalfedgesIter(v) {
+		res = append(res, he.End)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// sortedVertices returns all vertices of the graph, sorted by name.
+func (g *Graph) sortedVertices() []string {
+	res := make([]string, 0, len(g.Adjacency))
+	for v := range g.Adjacency {
+		res = append(res, v)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// StronglyConnectedComponents returns the strongly connected components of the
+// directed graph g, computed with Tarjan's algorithm.
+// Every vertex is part of exactly one component. The vertices of a component
+// are sorted by name, the components are sorted by their first vertex.
+func StronglyConnectedComponents(g *Graph) [][]string {
+	var (
+		index    int
+		stack    []string
+		onStack  = map[string]bool{}
+		indices  = map[string]int{}
+		lowlinks = map[string]int{}
+		result   [][]string
+	)
+
+	var strongConnect func(v string)
+	strongConnect = func(v string) {
+		indices[v] = index
+		lowlinks[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range g.sortedNeighbors(v) {
+			if _, visited := indices[w]; !visited {
+				strongConnect(w)
+				lowlinks[v] = min(lowlinks[v], lowlinks[w])
+			} else if onStack[w] {
+				lowlinks[v] = min(lowlinks[v], indices[w])
+			}
+		}
+
+		if lowlinks[v] != indices[v] {
+			return
+		}
+
+		var component []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		slices.Sort(component)
+		result = append(result, component)
+	}
+
+	for _, v := range g.sortedVertices() {
+		if _, visited := indices[v]; !visited {
+			strongConnect(v)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b []string) int {
+		return slices.Compare(a, b)
+	})
+
+	return result
+}
+
+// HasEdge returns true if the graph contains an edge from v1 to v2.
+func (g *Graph) HasEdge(v1, v2 string) bool {
+	s, exists := g.Adjacency[v1]
+	if !exists {
+		return false
+	}
+	_, exists = (*s)[Halfedge{End: v2}]
+	return exists
+}
+
+// FindCycle returns a shortest cycle that starts and ends at the first vertex
+// of component and only passes vertices of component.
+// component must be a strongly connected component of g, as returned by
+// StronglyConnectedComponents.
+// The returned slice contains the vertices of the cycle in order, the edge
+// from the last vertex back to the first one is implicit.
+// If component does not contain a cycle, nil is returned.
+func FindCycle(g *Graph, component []string) []string {
+	if len(component) == 0 {
+		return nil
+	}
+
+	start := component[0]
+	if len(component) == 1 {
+		if g.HasEdge(start, start) {
+			return []string{start}
+		}
+		return nil
+	}
+
+	inComponent := make(map[string]struct{}, len(component))
+	for _, v := range component {
+		inComponent[v] = struct{}{}
+	}
+
+	parents := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+
+		for _, w := range g.sortedNeighbors(v) {
+			if _, exists := inComponent[w]; !exists {
+				continue
+			}
+
+			if w == start {
+				cycle := []string{v}
+				for v != start {
+					v = parents[v]
+					cycle = append(cycle, v)
+				}
+				slices.Reverse(cycle)
+				return cycle
+			}
+
+			if _, visited := parents[w]; visited {
+				continue
+			}
+			parents[w] = v
+			queue = append(queue, w)
+		}
+	}
+
+	return nil
+}
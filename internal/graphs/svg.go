@@ -0,0 +1,403 @@
+package graphs
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	svgMargin      = 20
+	svgLabelWidth  = 70
+	svgNodeHeight  = 30
+	svgNodeGap     = 20
+	svgLayerGap    = 70
+	svgDummyWidth  = 10
+	svgCharWidth   = 7
+	svgNodePadding = 20
+	svgMinWidth    = 60
+	// svgSweeps is the number of down- and upward sweeps of the crossing
+	// reduction.
+	svgSweeps = 12
+)
+
+// SVG is a directed graph that is rendered as SVG image with a layered
+// (Sugiyama-style) layout.
+// Each node is placed in a layer, the first layer is drawn at the top. The
+// order of the nodes in a layer is chosen to reduce edge crossings with the
+// barycenter heuristic. Edges that span multiple layers are routed through
+// the layers in between. Dotted edges are drawn dashed.
+type SVG struct {
+	edgeSet
+	title      string
+	layerLabel string
+	layers     map[string]int
+}
+
+// NewSVG returns an empty SVG graph. If title is not empty, it is drawn
+// above the graph. If layerLabel is not empty, each layer is labeled with
+// layerLabel followed by the number of the layer, starting at 1.
+func NewSVG(title, layerLabel string) *SVG {
+	return &SVG{
+		edgeSet:    newEdgeSet(),
+		title:      title,
+		layerLabel: layerLabel,
+		layers:     map[string]int{},
+	}
+}
+
+// SetLayer places the node in the layer with the 0-based index layer.
+// If no layers are set, they are derived from the edges, nodes without
+// outgoing edges are placed in the first layer and every other node in the
+// layer after its deepest successor. Nodes without a layer are placed in the
+// first layer, if layers were set for other nodes.
+func (g *SVG) SetLayer(name string, layer int) {
+	g.layers[name] = layer
+}
+
+// svgNode is a node of the layout. Dummy nodes are inserted where edges cross
+// layers.
+type svgNode struct {
+	name  string
+	dummy bool
+	layer int
+	width float64
+	x, y  float64
+	// up and down are the indexes of the adjacent nodes in the previous and
+	// next layer.
+	up, down []int
+}
+
+// svgEdge is an edge of the graph with the layout nodes it passes through.
+type svgEdge struct {
+	writerEdge
+	path []int
+}
+
+func (g *SVG) String() string {
+	nodes, edges, order := g.layout()
+
+	width, height := 0.0, 0.0
+	for _, n := range nodes {
+		width = math.Max(width, n.x+n.width)
+		height = math.Max(height, n.y+svgNodeHeight)
+	}
+	width += svgMargin
+	height += svgMargin
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	sb.WriteString(`  <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#555"/></marker></defs>` + "\n")
+	sb.WriteString(`  <rect width="100%" height="100%" fill="white"/>` + "\n")
+
+	if g.title != "" {
+		fmt.Fprintf(&sb, `  <text x="%d" y="%d" font-size="16" font-weight="bold">%s</text>`+"\n",
+			svgMargin, svgMargin+12, xmlEscape(g.title))
+	}
+
+	if g.layerLabel != "" {
+		for i, layer := range order {
+			if len(layer) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, `  <text x="%d" y="%.0f" fill="#888" dominant-baseline="middle">%s %d</text>`+"\n",
+				svgMargin, nodes[layer[0]].y+svgNodeHeight/2, xmlEscape(g.layerLabel), i+1)
+		}
+	}
+
+	sb.WriteString(`  <g fill="none" stroke="#555">` + "\n")
+	for _, e := range edges {
+		dash := ""
+		if e.dotted {
+			dash = ` stroke-dasharray="6 4"`
+		}
+		fmt.Fprintf(&sb, `    <path d="%s" marker-end="url(#arrow)"%s><title>%s -&gt; %s</title></path>`+"\n",
+			svgEdgePath(nodes, e.path), dash, xmlEscape(e.src), xmlEscape(e.dest))
+	}
+	sb.WriteString("  </g>\n")
+
+	for _, n := range nodes {
+		if n.dummy {
+			continue
+		}
+		fmt.Fprintf(&sb, `  <g><rect x="%.0f" y="%.0f" width="%.0f" height="%d" rx="4" fill="#f4f6f8" stroke="#2d3e50"/>`+
+			`<text x="%.0f" y="%.0f" text-anchor="middle" dominant-baseline="middle">%s</text></g>`+"\n",
+			n.x, n.y, n.width, svgNodeHeight, n.x+n.width/2, n.y+svgNodeHeight/2, xmlEscape(n.name))
+	}
+
+	sb.WriteString("</svg>\n")
+
+	return sb.String()
+}
+
+// svgEdgePath returns the SVG path data of an edge that passes through the
+// nodes path.
+func svgEdgePath(nodes []*svgNode, path []int) string {
+	src, dest := nodes[path[0]], nodes[path[len(path)-1]]
+
+	if src.layer == dest.layer {
+		// arc above the layer
+		x1, x2 := src.x+src.width/2, dest.x+dest.width/2
+		y := src.y
+		return fmt.Sprintf("M %.1f %.1f C %.1f %.1f, %.1f %.1f, %.1f %.1f",
+			x1, y, x1, y-svgLayerGap/2, x2, y-svgLayerGap/2, x2, y)
+	}
+
+	points := make([][2]float64, 0, len(path))
+	for i, idx := range path {
+		n := nodes[idx]
+		x, y := n.x+n.width/2, n.y+svgNodeHeight/2
+		switch {
+		case n.dummy:
+		case i == 0 && dest.layer < src.layer, i == len(path)-1 && dest.layer > src.layer:
+			y = n.y
+		default:
+			y = n.y + svgNodeHeight
+		}
+		points = append(points, [2]float64{x, y})
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "M %.1f %.1f", points[0][0], points[0][1])
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		midY := (p[1] + q[1]) / 2
+		fmt.Fprintf(&sb, " C %.1f %.1f, %.1f %.1f, %.1f %.1f", p[0], midY, q[0], midY, q[0], q[1])
+	}
+
+	return sb.String()
+}
+
+// layout assigns coordinates to the nodes. It returns the layout nodes, the
+// edges and the order of the node indexes in each layer.
+func (g *SVG) layout() ([]*svgNode, []*svgEdge, [][]int) {
+	layers := g.layers
+	if len(layers) == 0 {
+		layers = g.longestPathLayers()
+	}
+
+	var nodes []*svgNode
+	idx := map[string]int{}
+	maxLayer := 0
+	for _, name := range g.sortedNodes() {
+		layer := layers[name]
+		maxLayer = max(maxLayer, layer)
+		idx[name] = len(nodes)
+		nodes = append(nodes, &svgNode{
+			name:  name,
+			layer: layer,
+			width: max(float64(len([]rune(name))*svgCharWidth+svgNodePadding), svgMinWidth),
+		})
+	}
+
+	var edges []*svgEdge
+	for _, e := range g.sortedEdges() {
+		src, dest := idx[e.src], idx[e.dest]
+		edge := svgEdge{writerEdge: e, path: []int{src}}
+
+		step := 1
+		if nodes[dest].layer < nodes[src].layer {
+			step = -1
+		}
+
+		prev := src
+		for l := nodes[src].layer + step; l != nodes[dest].layer && nodes[src].layer != nodes[dest].layer; l += step {
+			nodes = append(nodes, &svgNode{dummy: true, layer: l, width: svgDummyWidth})
+			cur := len(nodes) - 1
+			connect(nodes, prev, cur)
+			edge.path = append(edge.path, cur)
+			prev = cur
+		}
+		if nodes[src].layer != nodes[dest].layer {
+			connect(nodes, prev, dest)
+		}
+		edge.path = append(edge.path, dest)
+
+		edges = append(edges, &edge)
+	}
+
+	order := make([][]int, maxLayer+1)
+	for i, n := range nodes {
+		order[n.layer] = append(order[n.layer], i)
+	}
+
+	order = reduceCrossings(nodes, order)
+	assignCoordinates(nodes, order, g.title != "", g.layerLabel != "")
+
+	return nodes, edges, order
+}
+
+// connect adds an adjacency between the nodes a and b, that are in adjacent
+// layers.
+func connect(nodes []*svgNode, a, b int) {
+	if nodes[a].layer > nodes[b].layer {
+		a, b = b, a
+	}
+	nodes[a].down = append(nodes[a].down, b)
+	nodes[b].up = append(nodes[b].up, a)
+}
+
+// longestPathLayers places nodes without outgoing edges in layer 0 and every
+// other node one layer after its deepest successor. Edges that close loops
+// are ignored.
+func (g *SVG) longestPathLayers() map[string]int {
+	succ := map[string][]string{}
+	for e := range g.edges {
+		succ[e.src] = append(succ[e.src], e.dest)
+	}
+
+	res := map[string]int{}
+	visiting := map[string]bool{}
+	var depth func(string) int
+	depth = func(n string) int {
+		if d, exists := res[n]; exists {
+			return d
+		}
+
+		visiting[n] = true
+		d := 0
+		for _, s := range succ[n] {
+			if !visiting[s] {
+				d = max(d, depth(s)+1)
+			}
+		}
+		visiting[n] = false
+
+		res[n] = d
+		return d
+	}
+
+	for _, n := range g.sortedNodes() {
+		depth(n)
+	}
+
+	return res
+}
+
+// reduceCrossings reorders the nodes in the layers with the barycenter
+// heuristic and returns the order with the fewest crossings.
+func reduceCrossings(nodes []*svgNode, order [][]int) [][]int {
+	pos := make([]float64, len(nodes))
+	updatePos := func() {
+		for _, layer := range order {
+			for i, n := range layer {
+				pos[n] = float64(i)
+			}
+		}
+	}
+	sortLayer := func(layer []int, neighbors func(*svgNode) []int) {
+		bary := make(map[int]float64, len(layer))
+		for _, n := range layer {
+			adj := neighbors(nodes[n])
+			if len(adj) == 0 {
+				bary[n] = pos[n]
+				continue
+			}
+			sum := 0.0
+			for _, a := range adj {
+				sum += pos[a]
+			}
+			bary[n] = sum / float64(len(adj))
+		}
+		slices.SortStableFunc(layer, func(a, b int) int {
+			switch {
+			case bary[a] < bary[b]:
+				return -1
+			case bary[a] > bary[b]:
+				return 1
+			}
+			return 0
+		})
+		for i, n := range layer {
+			pos[n] = float64(i)
+		}
+	}
+
+	updatePos()
+	best := cloneOrder(order)
+	bestCrossings := crossings(nodes, order, pos)
+
+	for range svgSweeps {
+		for l := 1; l < len(order); l++ {
+			sortLayer(order[l], func(n *svgNode) []int { return n.up })
+		}
+		for l := len(order) - 2; l >= 0; l-- {
+			sortLayer(order[l], func(n *svgNode) []int { return n.down })
+		}
+
+		if c := crossings(nodes, order, pos); c < bestCrossings {
+			best = cloneOrder(order)
+			bestCrossings = c
+		}
+	}
+
+	return best
+}
+
+func cloneOrder(order [][]int) [][]int {
+	res := make([][]int, len(order))
+	for i, layer := range order {
+		res[i] = slices.Clone(layer)
+	}
+	return res
+}
+
+// crossings returns the number of crossings between the adjacencies of
+// adjacent layers.
+func crossings(nodes []*svgNode, order [][]int, pos []float64) int {
+	res := 0
+	for l := 0; l < len(order)-1; l++ {
+		var segs [][2]float64
+		for _, n := range order[l] {
+			for _, d := range nodes[n].down {
+				segs = append(segs, [2]float64{pos[n], pos[d]})
+			}
+		}
+
+		for i := range segs {
+			for j := i + 1; j < len(segs); j++ {
+				if (segs[i][0]-segs[j][0])*(segs[i][1]-segs[j][1]) < 0 {
+					res++
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// assignCoordinates places the nodes of each layer next to each other,
+// centered horizontally.
+func assignCoordinates(nodes []*svgNode, order [][]int, hasTitle, hasLabels bool) {
+	left := float64(svgMargin)
+	if hasLabels {
+		left += svgLabelWidth
+	}
+	top := float64(svgMargin)
+	if hasTitle {
+		top += 30
+	}
+
+	widths := make([]float64, len(order))
+	maxWidth := 0.0
+	for l, layer := range order {
+		for i, n := range layer {
+			if i > 0 {
+				widths[l] += svgNodeGap
+			}
+			widths[l] += nodes[n].width
+		}
+		maxWidth = math.Max(maxWidth, widths[l])
+	}
+
+	for l, layer := range order {
+		x := left + (maxWidth-widths[l])/2
+		for _, n := range layer {
+			nodes[n].x = x
+			nodes[n].y = top + float64(l*(svgNodeHeight+svgLayerGap))
+			x += nodes[n].width + svgNodeGap
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package graphs
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/datastructs"
+)
+
+// RedundantEdges returns the edges of the directed acyclic graph g, that are
+// not part of its transitive reduction.
+// An edge from u to v is redundant if v can also be reached from u via a
+// path of 2 or more edges.
+// The edges are sorted by their start and end vertices.
+// If g contains a loop, ErrNoDAG is returned.
+func RedundantEdges(g *Graph) ([]Edge, error) {
+	sorted, _, err := TopologicalSort(g)
+	if err != nil {
+		return nil, err
+	}
+
+	order := datastructs.ListToSlice(sorted)
+
+	// reachable[v] contains all vertices that can be reached from v via
+	// paths of 1 or more edges, vertices are processed in reverse
+	// topological order, all successors of a vertex are processed before
+	// it
+	reachable := make(map[string]map[string]struct{}, len(order))
+	var res []Edge
+	for _, v := range slices.Backward(order) {
+		successors := g.sortedNeighbors(v)
+
+		// transitive contains the vertices reachable via paths of 2 or
+		// more edges
+		transitive := map[string]struct{}{}
+		for _, s := range successors {
+			for r := range reachable[s] {
+				transitive[r] = struct{}{}
+			}
+		}
+
+		reach := make(map[string]struct{}, len(transitive)+len(successors))
+		for r := range transitive {
+			reach[r] = struct{}{}
+		}
+		for _, s := range successors {
+			reach[s] = struct{}{}
+			if _, exists := transitive[s]; exists {
+				res = append(res, Edge{Start: v, End: s})
+			}
+		}
+		reachable[v] = reach
+	}
+
+	slices.SortFunc(res, func(a, b Edge) int {
+		if r := strings.Compare(a.Start, b.Start); r != 0 {
+			return r
+		}
+		return strings.Compare(a.End, b.End)
+	})
+
+	return res, nil
+}
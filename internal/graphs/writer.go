@@ -0,0 +1,140 @@
+package graphs
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// GraphWriter creates a textual representation of a directed graph.
+// Adding a node that already exists is not an error.
+type GraphWriter interface {
+	AddNode(name string) error
+	AddEdge(src, dest string) error
+	// AddDottedEdge adds an edge that is drawn dotted or dashed.
+	AddDottedEdge(src, dest string) error
+	String() string
+}
+
+var (
+	_ GraphWriter = &Dot{}
+	_ GraphWriter = &Mermaid{}
+	_ GraphWriter = &PlantUML{}
+	_ GraphWriter = &GraphML{}
+	_ GraphWriter = &SVG{}
+)
+
+// EdgeLabels are the names of the kinds of edges, for formats that label
+// edges.
+type EdgeLabels struct {
+	Edge       string
+	DottedEdge string
+}
+
+// edgeSet stores the nodes and edges of a graph for writers that output
+// them sorted by name, independent of the order in which they were added.
+type edgeSet struct {
+	nodes map[string]struct{}
+	edges map[writerEdge]struct{}
+}
+
+type writerEdge struct {
+	src, dest string
+	dotted    bool
+}
+
+func newEdgeSet() edgeSet {
+	return edgeSet{
+		nodes: map[string]struct{}{},
+		edges: map[writerEdge]struct{}{},
+	}
+}
+
+func (s *edgeSet) AddNode(name string) error {
+	s.nodes[name] = struct{}{}
+	return nil
+}
+
+func (s *edgeSet) AddEdge(src, dest string) error {
+	return s.addEdge(src, dest, false)
+}
+
+func (s *edgeSet) AddDottedEdge(src, dest string) error {
+	return s.addEdge(src, dest, true)
+}
+
+func (s *edgeSet) addEdge(src, dest string, dotted bool) error {
+	for _, n := range []string{src, dest} {
+		if _, exists := s.nodes[n]; !exists {
+			return fmt.Errorf("node %q does not exist", n)
+		}
+	}
+
+	s.edges[writerEdge{src: src, dest: dest, dotted: dotted}] = struct{}{}
+	return nil
+}
+
+func (s *edgeSet) sortedNodes() []string {
+	return slices.Sorted(maps.Keys(s.nodes))
+}
+
+func (s *edgeSet) sortedEdges() []writerEdge {
+	edges := slices.Collect(maps.Keys(s.edges))
+	slices.SortFunc(edges, func(a, b writerEdge) int {
+		if c := cmp.Compare(a.src, b.src); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.dest, b.dest); c != 0 {
+			return c
+		}
+		if a.dotted == b.dotted {
+			return 0
+		}
+		if a.dotted {
+			return 1
+		}
+		return -1
+	})
+
+	return edges
+}
+
+// uniqueIDs returns unique node IDs for the sorted node names.
+// sanitize converts a name to an ID, if multiple names result in the same ID,
+// a numeric suffix is appended.
+func uniqueIDs(nodes []string, sanitize func(string) string) map[string]string {
+	res := make(map[string]string, len(nodes))
+	used := make(map[string]struct{}, len(nodes))
+
+	for _, n := range nodes {
+		base := sanitize(n)
+		id := base
+		for i := 2; ; i++ {
+			if _, exists := used[id]; !exists {
+				break
+			}
+			id = fmt.Sprintf("%s_%d", base, i)
+		}
+
+		used[id] = struct{}{}
+		res[n] = id
+	}
+
+	return res
+}
+
+// sanitizeID returns prefix followed by name, in which all characters except
+// ASCII letters, digits and underscores are replaced by underscores.
+func sanitizeID(prefix, name string) string {
+	res := []rune(prefix)
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			res = append(res, r)
+			continue
+		}
+		res = append(res, '_')
+	}
+
+	return string(res)
+}
@@ -0,0 +1,33 @@
+// Package jsontime provides time types with a human-readable JSON encoding.
+package jsontime
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Duration is a time.Duration that is JSON encoded as string in the
+// format of time.Duration.String, e.g. "5m30s", like durations in
+// configuration files.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string in the format accepted by
+// time.ParseDuration.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
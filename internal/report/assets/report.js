@@ -0,0 +1,342 @@
+"use strict";
+
+(function () {
+  const apps = new Map(REPORT.apps.map((a) => [a.name, a]));
+  let selected = null;
+
+  function el(tag, attrs, ...children) {
+    const e = document.createElement(tag);
+    for (const [k, v] of Object.entries(attrs || {})) {
+      e.setAttribute(k, v);
+    }
+    for (const c of children) {
+      e.append(c);
+    }
+    return e;
+  }
+
+  function svgEl(tag, attrs) {
+    const e = document.createElementNS("http://www.w3.org/2000/svg", tag);
+    for (const [k, v] of Object.entries(attrs || {})) {
+      e.setAttribute(k, v);
+    }
+    return e;
+  }
+
+  function appLink(name) {
+    const a = el("a", { class: "app" }, name);
+    a.addEventListener("click", () => selectApp(name));
+    return a;
+  }
+
+  function appList(names) {
+    if (names.length === 0) {
+      return el("span", { class: "muted" }, "none");
+    }
+    const span = el("span");
+    names.forEach((n, i) => {
+      if (i > 0) {
+        span.append(", ");
+      }
+      span.append(appLink(n));
+    });
+    return span;
+  }
+
+  // transitive returns all apps that are reachable from name via the
+  // neighbours returned by next.
+  function transitive(name, next) {
+    const seen = new Set();
+    const queue = [name];
+    while (queue.length > 0) {
+      for (const n of next(apps.get(queue.shift()))) {
+        if (!seen.has(n) && n !== name) {
+          seen.add(n);
+          queue.push(n);
+        }
+      }
+    }
+    return [...seen].sort();
+  }
+
+  function showView(name) {
+    document.querySelectorAll("nav button").forEach((b) => {
+      b.classList.toggle("active", b.dataset.view === name);
+    });
+    document.querySelectorAll(".view").forEach((v) => {
+      v.classList.toggle("active", v.id === "view-" + name);
+    });
+    if (name === "graph") {
+      renderGraph();
+    }
+  }
+
+  function selectApp(name) {
+    selected = name;
+    document.querySelectorAll("#app-list li").forEach((li) => {
+      li.classList.toggle("selected", li.dataset.app === name);
+    });
+    renderApp();
+    if (document.getElementById("view-graph").classList.contains("active")) {
+      highlightGraph();
+    } else {
+      showView("app");
+    }
+  }
+
+  function renderAppList() {
+    const list = document.getElementById("app-list");
+    for (const app of REPORT.apps) {
+      const li = el("li", {}, app.name);
+      li.dataset.app = app.name;
+      li.addEventListener("click", () => selectApp(app.name));
+      list.append(li);
+    }
+
+    document.getElementById("search").addEventListener("input", (ev) => {
+      const q = ev.target.value.toLowerCase();
+      list.querySelectorAll("li").forEach((li) => {
+        li.hidden = !li.dataset.app.toLowerCase().includes(q);
+      });
+    });
+  }
+
+  function renderApp() {
+    const view = document.getElementById("view-app");
+    view.replaceChildren();
+    if (selected === null) {
+      view.append(el("p", { class: "muted" }, "Select an app to show its dependencies and dependents."));
+      return;
+    }
+
+    const app = apps.get(selected);
+    const rows = [
+      ["Configuration file", app.source_file || "unknown"],
+      ["Wave", app.wave > 0 ? String(app.wave) : "unknown, hard dependencies contain loops"],
+      ["Hard dependencies", appList(app.hard_dependencies)],
+      ["Soft dependencies", appList(app.soft_dependencies)],
+      ["All dependencies", appList(transitive(app.name, (a) => a.hard_dependencies.concat(a.soft_dependencies)))],
+      ["Hard dependents", appList(app.hard_dependents)],
+      ["Soft dependents", appList(app.soft_dependents)],
+      ["All dependents", appList(transitive(app.name, (a) => a.hard_dependents.concat(a.soft_dependents)))],
+    ];
+
+    const table = el("table");
+    for (const [k, v] of rows) {
+      table.append(el("tr", {}, el("th", { align: "left" }, k), el("td", {}, v)));
+    }
+    view.append(el("h2", {}, app.name), table);
+
+    const findings = REPORT.findings.filter((f) => f.apps.includes(app.name));
+    if (findings.length > 0) {
+      view.append(el("h3", {}, "Findings"));
+      findings.forEach((f) => view.append(renderFinding(f)));
+    }
+  }
+
+  function renderWaves() {
+    const view = document.getElementById("view-waves");
+    if (REPORT.waves.length === 0) {
+      view.append(el("p", {}, "No deployment waves, the hard dependencies contain loops."));
+      return;
+    }
+    REPORT.waves.forEach((wave, i) => {
+      view.append(el("div", { class: "wave" }, el("h3", {}, "Wave " + (i + 1)), appList(wave)));
+    });
+  }
+
+  function renderFinding(f) {
+    return el("div", { class: "finding " + f.severity }, el("strong", {}, f.severity + ": "), f.message,
+      el("div", {}, appList(f.apps)));
+  }
+
+  function renderFindings() {
+    const view = document.getElementById("view-findings");
+    document.getElementById("findings-count").textContent = REPORT.findings.length;
+    if (REPORT.findings.length === 0) {
+      view.append(el("p", {}, "No findings."));
+      return;
+    }
+    REPORT.findings.forEach((f) => view.append(renderFinding(f)));
+  }
+
+  // layers returns the column of every app in the graph. If the waves are
+  // unknown, the length of the longest hard dependency path is used,
+  // ignoring edges that close loops.
+  function layers() {
+    const res = new Map();
+    if (REPORT.waves.length > 0) {
+      REPORT.apps.forEach((a) => res.set(a.name, a.wave - 1));
+      return res;
+    }
+
+    const visiting = new Set();
+    function depth(name) {
+      if (res.has(name)) {
+        return res.get(name);
+      }
+      visiting.add(name);
+      let d = 0;
+      for (const dep of apps.get(name).hard_dependencies) {
+        if (!visiting.has(dep)) {
+          d = Math.max(d, depth(dep) + 1);
+        }
+      }
+      visiting.delete(name);
+      res.set(name, d);
+      return d;
+    }
+    REPORT.apps.forEach((a) => depth(a.name));
+    return res;
+  }
+
+  let graphRendered = false;
+  const nodeWidth = 180;
+  const nodeHeight = 28;
+
+  function renderGraph() {
+    if (graphRendered) {
+      highlightGraph();
+      return;
+    }
+    graphRendered = true;
+
+    const svg = document.getElementById("graph");
+    const defs = svgEl("defs");
+    const marker = svgEl("marker", {
+      id: "arrow", viewBox: "0 0 10 10", refX: "10", refY: "5",
+      markerWidth: "8", markerHeight: "8", orient: "auto-start-reverse",
+    });
+    marker.append(svgEl("path", { d: "M 0 0 L 10 5 L 0 10 z", fill: "#999" }));
+    defs.append(marker);
+    svg.append(defs);
+
+    const columns = [];
+    for (const [name, layer] of layers()) {
+      (columns[layer] = columns[layer] || []).push(name);
+    }
+
+    const pos = new Map();
+    columns.forEach((names, x) => {
+      names.sort().forEach((name, y) => {
+        pos.set(name, { x: 20 + x * (nodeWidth + 80), y: 20 + y * (nodeHeight + 14) });
+      });
+    });
+
+    const edgeGroup = svgEl("g");
+    const nodeGroup = svgEl("g");
+    svg.append(edgeGroup, nodeGroup);
+
+    for (const app of REPORT.apps) {
+      const deps = app.hard_dependencies.map((d) => [d, "hard"])
+        .concat(app.soft_dependencies.map((d) => [d, "soft"]));
+      for (const [dep, type] of deps) {
+        const from = pos.get(app.name);
+        const to = pos.get(dep);
+        const x1 = from.x;
+        const y1 = from.y + nodeHeight / 2;
+        const x2 = to.x + nodeWidth;
+        const y2 = to.y + nodeHeight / 2;
+        const path = svgEl("path", {
+          d: `M ${x1} ${y1} C ${x1 - 40} ${y1}, ${x2 + 40} ${y2}, ${x2} ${y2}`,
+          class: "edge " + type,
+        });
+        path.dataset.from = app.name;
+        path.dataset.to = dep;
+        edgeGroup.append(path);
+      }
+    }
+
+    for (const [name, p] of pos) {
+      const g = svgEl("g", { class: "node", transform: `translate(${p.x},${p.y})` });
+      g.dataset.app = name;
+      const title = svgEl("title");
+      title.textContent = name;
+      const text = svgEl("text", { x: nodeWidth / 2, y: nodeHeight / 2 });
+      text.textContent = name.length > 24 ? name.slice(0, 23) + "…" : name;
+      g.append(title, svgEl("rect", { width: nodeWidth, height: nodeHeight }), text);
+      g.addEventListener("click", (ev) => {
+        ev.stopPropagation();
+        selectApp(name);
+      });
+      nodeGroup.append(g);
+    }
+
+    const bbox = { w: columns.length * (nodeWidth + 80) + 40, h: 0 };
+    columns.forEach((c) => {
+      bbox.h = Math.max(bbox.h, c.length * (nodeHeight + 14) + 40);
+    });
+    let view = { x: 0, y: 0, w: bbox.w, h: bbox.h };
+    const applyView = () => svg.setAttribute("viewBox", `${view.x} ${view.y} ${view.w} ${view.h}`);
+    applyView();
+
+    let drag = null;
+    svg.addEventListener("mousedown", (ev) => {
+      drag = { x: ev.clientX, y: ev.clientY, view: { ...view } };
+    });
+    window.addEventListener("mouseup", () => {
+      drag = null;
+    });
+    svg.addEventListener("mousemove", (ev) => {
+      if (drag === null) {
+        return;
+      }
+      const scale = view.w / svg.clientWidth;
+      view.x = drag.view.x - (ev.clientX - drag.x) * scale;
+      view.y = drag.view.y - (ev.clientY - drag.y) * scale;
+      applyView();
+    });
+    svg.addEventListener("wheel", (ev) => {
+      ev.preventDefault();
+      const factor = ev.deltaY > 0 ? 1.1 : 1 / 1.1;
+      const rect = svg.getBoundingClientRect();
+      const mx = view.x + (ev.clientX - rect.left) / rect.width * view.w;
+      const my = view.y + (ev.clientY - rect.top) / rect.height * view.h;
+      view = {
+        x: mx - (mx - view.x) * factor,
+        y: my - (my - view.y) * factor,
+        w: view.w * factor,
+        h: view.h * factor,
+      };
+      applyView();
+    }, { passive: false });
+    svg.addEventListener("click", () => {
+      selected = null;
+      highlightGraph();
+    });
+
+    highlightGraph();
+  }
+
+  function highlightGraph() {
+    if (!graphRendered) {
+      return;
+    }
+
+    let related = null;
+    if (selected !== null) {
+      related = new Set([selected]);
+      transitive(selected, (a) => a.hard_dependencies.concat(a.soft_dependencies)).forEach((n) => related.add(n));
+      transitive(selected, (a) => a.hard_dependents.concat(a.soft_dependents)).forEach((n) => related.add(n));
+    }
+
+    document.querySelectorAll("#graph .node").forEach((n) => {
+      n.classList.toggle("selected", n.dataset.app === selected);
+      n.classList.toggle("dimmed", related !== null && !related.has(n.dataset.app));
+    });
+    document.querySelectorAll("#graph .edge").forEach((e) => {
+      const isRelated = related !== null && related.has(e.dataset.from) && related.has(e.dataset.to);
+      e.classList.toggle("highlighted", isRelated);
+      e.classList.toggle("dimmed", related !== null && !isRelated);
+    });
+  }
+
+  document.querySelectorAll("nav button").forEach((b) => {
+    b.addEventListener("click", () => showView(b.dataset.view));
+  });
+
+  renderAppList();
+  renderApp();
+  renderWaves();
+  renderFindings();
+})();
@@ -0,0 +1,217 @@
+// Package report creates self-contained HTML reports of the dependencies of
+// a distribution.
+package report
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/cfg"
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Finding severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Report contains the data shown in the HTML report of a distribution.
+type Report struct {
+	Distribution string `json:"distribution"`
+	// Apps are sorted by name.
+	Apps []*App `json:"apps"`
+	// Waves are the deployment waves, they are empty if the hard
+	// dependencies contain loops.
+	Waves    [][]string   `json:"waves"`
+	Cycles   []deps.Cycle `json:"cycles"`
+	Findings []*Finding   `json:"findings"`
+}
+
+// App are the direct dependencies and dependents of an app.
+type App struct {
+	Name       string `json:"name"`
+	SourceFile string `json:"source_file"`
+	// Wave is the number of the deployment wave of the app, starting at 1.
+	// It is 0 if the hard dependencies contain loops.
+	Wave           int      `json:"wave"`
+	HardDeps       []string `json:"hard_dependencies"`
+	SoftDeps       []string `json:"soft_dependencies"`
+	HardDependents []string `json:"hard_dependents"`
+	SoftDependents []string `json:"soft_dependents"`
+}
+
+// Finding is an issue or a notable property of the dependencies.
+type Finding struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	// Apps are the names of the apps the finding is about.
+	Apps []string `json:"apps"`
+}
+
+// New creates the report for the distribution.
+func New(composition *deps.Composition, distribution string) (*Report, error) {
+	distr, exists := composition.Distribution[distribution]
+	if !exists {
+		return nil, fmt.Errorf("distribution %q not found", distribution)
+	}
+
+	r := Report{
+		Distribution: distribution,
+		Waves:        [][]string{},
+		Cycles:       []deps.Cycle{},
+		Findings:     []*Finding{},
+	}
+
+	apps := make(map[string]*App, len(distr))
+	for name, d := range distr {
+		apps[name] = &App{
+			Name:           name,
+			SourceFile:     d.SourceFile,
+			HardDeps:       sorted(d.HardDeps),
+			SoftDeps:       sorted(d.SoftDeps),
+			HardDependents: []string{},
+			SoftDependents: []string{},
+		}
+	}
+
+	edges, err := composition.Edges(distribution)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range edges {
+		// edges are sorted, the dependents are appended in order
+		if e.Type == cfg.TypeSoftDependency {
+			apps[e.To].SoftDependents = append(apps[e.To].SoftDependents, e.From)
+			continue
+		}
+		apps[e.To].HardDependents = append(apps[e.To].HardDependents, e.From)
+	}
+
+	if err := r.addWaves(composition, apps); err != nil {
+		return nil, err
+	}
+
+	if err := r.addFindings(composition); err != nil {
+		return nil, err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(apps)) {
+		r.Apps = append(r.Apps, apps[name])
+	}
+
+	return &r, nil
+}
+
+func (r *Report) addWaves(composition *deps.Composition, apps map[string]*App) error {
+	waves, err := composition.DependencyWaves(r.Distribution)
+	if err != nil {
+		var cycleErr *deps.CycleError
+		if errors.As(err, &cycleErr) {
+			r.Cycles = cycleErr.Cycles
+			return nil
+		}
+		return err
+	}
+
+	r.Waves = waves
+	for i, wave := range waves {
+		for _, app := range wave {
+			apps[app].Wave = i + 1
+		}
+	}
+
+	return nil
+}
+
+func (r *Report) addFindings(composition *deps.Composition) error {
+	for _, cycle := range r.Cycles {
+		r.Findings = append(r.Findings, &Finding{
+			Severity: SeverityError,
+			Message:  "hard dependency loop: " + cycle.String(),
+			Apps:     cycleApps(cycle),
+		})
+	}
+
+	// redundant dependencies can only be determined for acyclic graphs
+	if len(r.Cycles) == 0 {
+		redundant, err := composition.RedundantHardDependencies(r.Distribution)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range redundant {
+			r.Findings = append(r.Findings, &Finding{
+				Severity: SeverityWarning,
+				Message:  "redundant hard dependency " + e.String(),
+				Apps:     []string{e.From, e.To},
+			})
+		}
+	}
+
+	clusters, err := composition.SoftDependencyClusters(r.Distribution)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		r.Findings = append(r.Findings, &Finding{
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("%d apps depend on each other via soft dependencies and must be deployed together", len(cluster)),
+			Apps:     cluster,
+		})
+	}
+
+	return nil
+}
+
+func cycleApps(cycle deps.Cycle) []string {
+	res := make([]string, 0, len(cycle))
+	for _, e := range cycle {
+		res = append(res, e.From)
+	}
+	return res
+}
+
+// WriteHTML writes the report as a single HTML document to w.
+// The document does not reference any external resources.
+func (r *Report) WriteHTML(w io.Writer) error {
+	tmpl, err := template.ParseFS(assets, "assets/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+
+	css, err := assets.ReadFile("assets/report.css")
+	if err != nil {
+		return err
+	}
+
+	js, err := assets.ReadFile("assets/report.js")
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, map[string]any{
+		"Report": r,
+		// the assets are part of the binary and trusted
+		"CSS": template.CSS(css),
+		"JS":  template.JS(js),
+	})
+}
+
+func sorted(s []string) []string {
+	res := slices.Clone(s)
+	if res == nil {
+		return []string{}
+	}
+	slices.Sort(res)
+	return res
+}
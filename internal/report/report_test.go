@@ -0,0 +1,44 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+func TestReportWithLoop(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("prd", "a", &deps.Dependencies{HardDeps: []string{"b"}, SourceFile: "a/deps.yaml"})
+	comp.Add("prd", "b", &deps.Dependencies{HardDeps: []string{"a"}, SoftDeps: []string{"c"}})
+	comp.Add("prd", "c", &deps.Dependencies{SoftDeps: []string{"b"}})
+
+	r, err := New(comp, "prd")
+	require.NoError(t, err)
+
+	require.Len(t, r.Apps, 3)
+	assert.Equal(t, &App{
+		Name:           "b",
+		HardDeps:       []string{"a"},
+		SoftDeps:       []string{"c"},
+		HardDependents: []string{"a"},
+		SoftDependents: []string{"c"},
+	}, r.Apps[1])
+
+	assert.Empty(t, r.Waves)
+	require.Len(t, r.Cycles, 1)
+	require.Len(t, r.Findings, 2)
+	assert.Equal(t, SeverityError, r.Findings[0].Severity)
+	assert.Equal(t, []string{"a", "b"}, r.Findings[0].Apps)
+	assert.Equal(t, SeverityInfo, r.Findings[1].Severity)
+	assert.Equal(t, []string{"b", "c"}, r.Findings[1].Apps)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf))
+	assert.Contains(t, buf.String(), `"source_file":"a/deps.yaml"`)
+	assert.NotContains(t, buf.String(), "<script src=")
+	assert.NotContains(t, buf.String(), "<link ")
+}
@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes complete lines, prefixed with a string, to an
+// io.Writer that is shared with other writers.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix []byte
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	if w == nil {
+		w = io.Discard
+	}
+
+	return &prefixWriter{w: w, mu: mu, prefix: []byte(prefix)}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		idx := bytes.IndexByte(p.buf, '\n')
+		if idx == -1 {
+			return len(b), nil
+		}
+
+		if err := p.writeLine(p.buf[:idx+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[idx+1:]
+	}
+}
+
+// Flush writes the remaining incomplete line.
+func (p *prefixWriter) Flush() {
+	if len(p.buf) == 0 {
+		return
+	}
+
+	_ = p.writeLine(append(p.buf, '\n'))
+	p.buf = nil
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.w.Write(p.prefix); err != nil {
+		return err
+	}
+	_, err := p.w.Write(line)
+	return err
+}
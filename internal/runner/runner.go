@@ -0,0 +1,375 @@
+// Package runner executes commands for apps in dependency order.
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/fs"
+	"github.com/simplesurance/dependencies-tool/v3/internal/jsontime"
+)
+
+// Status is the result of executing the command for an app.
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	// StatusSkipped is the status of apps that were not executed because
+	// one of their hard dependencies did not succeed or the run was
+	// canceled.
+	StatusSkipped Status = "skipped"
+)
+
+// Result is the outcome of executing the command for an app.
+type Result struct {
+	App      string        `json:"app"`
+	Wave     int           `json:"wave"`
+	Status   Status        `json:"status"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	LogFile  string        `json:"log_file,omitempty"`
+}
+
+// Summary contains the results of all apps of a run.
+type Summary struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Skipped   int           `json:"skipped"`
+	Duration  time.Duration `json:"duration"`
+	// Results contains the results of all apps, ordered by wave and name.
+	Results []*Result `json:"results"`
+}
+
+// Err returns an error if the command for any app did not succeed.
+func (s *Summary) Err() error {
+	if s.Failed == 0 && s.Skipped == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d app(s) failed, %d app(s) were skipped", s.Failed, s.Skipped)
+}
+
+// Runner executes a command for each app.
+type Runner struct {
+	// Concurrency is the maximum number of commands that run in parallel.
+	Concurrency int
+	// Timeout is the maximum duration of a single execution of a command,
+	// 0 means no timeout.
+	Timeout time.Duration
+	// Retries is how often the execution of a failed command is retried.
+	Retries int
+	// LogDir is a directory to which the output of the command of each app
+	// is written, to a file named <APP>.log. If empty, the output is
+	// written to Output.
+	LogDir string
+	// Command returns the command line that is executed for the app.
+	Command func(app string, wave int) ([]string, error)
+	// Output receives the output of the commands, if LogDir is empty.
+	// Each line is prefixed with the name of the app.
+	Output io.Writer
+	// Log receives progress messages.
+	Log io.Writer
+	// Completed contains apps that already succeeded in a previous run.
+	// Their commands are not executed again, they are reported as
+	// succeeded with 0 attempts.
+	Completed map[string]struct{}
+	// OnResult, if set, is called when the result of an app is known.
+	// Calls are not made concurrently.
+	OnResult func(*Result)
+
+	logMu    sync.Mutex
+	resultMu sync.Mutex
+}
+
+// Run executes the command for all apps in waves.
+// The apps of a wave are executed in parallel, after all apps of the
+// previous waves finished.
+// hardDeps contains for every app the names of the apps it depends on.
+// If the command of an app fails, the commands of apps that depend on it are
+// not executed, independent apps are still executed.
+// When ctx is canceled, running commands are killed and the remaining apps are
+// skipped.
+func (r *Runner) Run(ctx context.Context, waves [][]string, hardDeps map[string][]string) *Summary {
+	start := time.Now()
+	concurrency := max(r.Concurrency, 1)
+
+	var summary Summary
+	results := map[string]*Result{}
+
+	for waveIdx, wave := range waves {
+		r.logf("wave %d: %d app(s)\n", waveIdx+1, len(wave))
+
+		waveResults := make([]*Result, len(wave))
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+
+		for i, app := range wave {
+			if _, exists := r.Completed[app]; exists {
+				waveResults[i] = &Result{App: app, Wave: waveIdx + 1, Status: StatusSucceeded}
+				r.logf("%s: already succeeded in a previous run\n", app)
+				r.notify(waveResults[i])
+				continue
+			}
+
+			skip := func(reason string) {
+				waveResults[i] = &Result{App: app, Wave: waveIdx + 1, Status: StatusSkipped, Error: reason}
+				r.logf("%s: skipped, %s\n", app, reason)
+				r.notify(waveResults[i])
+			}
+
+			if reason := skipReason(ctx, app, hardDeps, results); reason != "" {
+				skip(reason)
+				continue
+			}
+
+			// the run can be canceled while waiting for a free slot
+			if !acquire(ctx, sem) {
+				skip(canceledReason)
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				waveResults[i] = r.execute(ctx, app, waveIdx+1)
+				r.notify(waveResults[i])
+			}()
+		}
+		wg.Wait()
+
+		for _, res := range waveResults {
+			results[res.App] = res
+			summary.Results = append(summary.Results, res)
+			switch res.Status {
+			case StatusSucceeded:
+				summary.Succeeded++
+			case StatusFailed:
+				summary.Failed++
+			case StatusSkipped:
+				summary.Skipped++
+			}
+		}
+	}
+
+	summary.Duration = time.Since(start)
+
+	return &summary
+}
+
+// canceledReason is the skip reason of apps that were not executed because
+// the run was canceled.
+const canceledReason = "run was canceled"
+
+// acquire blocks until a slot in sem is free or ctx is canceled. It returns
+// true if a slot was acquired. If ctx is canceled, no slot is acquired.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+
+	// select chooses randomly when both cases are ready
+	if ctx.Err() != nil {
+		<-sem
+		return false
+	}
+
+	return true
+}
+
+// skipReason returns why the command of app must not be executed, if it
+// can be executed an empty string is returned.
+func skipReason(ctx context.Context, app string, hardDeps map[string][]string, results map[string]*Result) string {
+	if ctx.Err() != nil {
+		return canceledReason
+	}
+
+	for _, dep := range hardDeps[app] {
+		res, exists := results[dep]
+		if !exists {
+			// dependency is not part of the run
+			continue
+		}
+		if res.Status != StatusSucceeded {
+			return fmt.Sprintf("dependency %s %s", dep, res.Status)
+		}
+	}
+
+	return ""
+}
+
+// execute runs the command of app, it is retried on failure up to
+// r.Retries times.
+func (r *Runner) execute(ctx context.Context, app string, wave int) *Result {
+	res := Result{App: app, Wave: wave}
+	start := time.Now()
+	defer func() { res.Duration = time.Since(start) }()
+
+	args, err := r.Command(app, wave)
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = fmt.Sprintf("creating command failed: %s", err)
+		r.logf("%s: failed, %s\n", app, res.Error)
+		return &res
+	}
+	if len(args) == 0 {
+		res.Status = StatusFailed
+		res.Error = "command is empty"
+		r.logf("%s: failed, %s\n", app, res.Error)
+		return &res
+	}
+
+	if r.LogDir != "" {
+		res.LogFile, err = logFilePath(r.LogDir, app)
+		if err != nil {
+			res.Status = StatusFailed
+			res.Error = err.Error()
+			r.logf("%s: failed, %s\n", app, res.Error)
+			return &res
+		}
+	}
+
+	out, closeOut, err := r.output(app, res.LogFile)
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = fmt.Sprintf("creating log file failed: %s", err)
+		r.logf("%s: failed, %s\n", app, res.Error)
+		return &res
+	}
+	defer closeOut()
+
+	for attempt := 1; attempt <= r.Retries+1; attempt++ {
+		res.Attempts = attempt
+		r.logf("%s: executing %q (attempt %d)\n", app, args, attempt)
+
+		err = r.runCommand(ctx, args, out)
+		if err == nil {
+			res.Status = StatusSucceeded
+			res.Error = ""
+			r.logf("%s: succeeded\n", app)
+			return &res
+		}
+
+		res.Status = StatusFailed
+		res.Error = err.Error()
+		r.logf("%s: attempt %d failed: %s\n", app, attempt, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return &res
+}
+
+func (r *Runner) runCommand(ctx context.Context, args []string, out io.Writer) error {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = 5 * time.Second
+
+	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timeout of %s exceeded: %w", r.Timeout, err)
+	}
+	return err
+}
+
+// logFilePath returns the path of the log file of app in dir. An error is
+// returned if the name of app can not be used as file name.
+func logFilePath(dir, app string) (string, error) {
+	if !fs.IsFileName(app) {
+		return "", fmt.Errorf("app name %q can not be used as log file name", app)
+	}
+
+	return filepath.Join(dir, app+".log"), nil
+}
+
+// output returns the writer for the output of the command of app and a
+// function that must be called when the command finished.
+// If logFile is empty, the output is written prefixed with the app name
+// to r.Output, otherwise to logFile.
+func (r *Runner) output(app, logFile string) (io.Writer, func(), error) {
+	if logFile == "" {
+		w := newPrefixWriter(r.Output, &r.logMu, app+": ")
+		return w, w.Flush, nil
+	}
+
+	f, err := os.Create(logFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return f, func() { _ = f.Close() }, nil
+}
+
+func (r *Runner) notify(res *Result) {
+	if r.OnResult == nil {
+		return
+	}
+
+	r.resultMu.Lock()
+	defer r.resultMu.Unlock()
+	r.OnResult(res)
+}
+
+func (r *Runner) logf(format string, a ...any) {
+	if r.Log == nil {
+		return
+	}
+
+	r.logMu.Lock()
+	defer r.logMu.Unlock()
+	fmt.Fprintf(r.Log, format, a...)
+}
+
+// MarshalJSON encodes r, Duration is encoded as jsontime.Duration.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		Duration jsontime.Duration `json:"duration"`
+	}{plain(r), jsontime.Duration(r.Duration)})
+}
+
+// UnmarshalJSON decodes r, Duration is decoded as jsontime.Duration.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	type plain Result
+	v := struct {
+		*plain
+		Duration jsontime.Duration `json:"duration"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	r.Duration = time.Duration(v.Duration)
+	return nil
+}
+
+// MarshalJSON encodes s, Duration is encoded as jsontime.Duration.
+func (s Summary) MarshalJSON() ([]byte, error) {
+	type plain Summary
+	return json.Marshal(struct {
+		plain
+		Duration jsontime.Duration `json:"duration"`
+	}{plain(s), jsontime.Duration(s.Duration)})
+}
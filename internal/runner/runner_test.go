@@ -0,0 +1,128 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSkipsDependentsOfFailedApps(t *testing.T) {
+	logDir := t.TempDir()
+
+	r := Runner{
+		Concurrency: 2,
+		Retries:     1,
+		LogDir:      logDir,
+		Command: func(app string, _ int) ([]string, error) {
+			if app == "b" {
+				return []string{"sh", "-c", "echo failing; exit 1"}, nil
+			}
+			return []string{"sh", "-c", "echo " + app}, nil
+		},
+	}
+
+	// c depends on b, d depends on a
+	waves := [][]string{{"a", "b"}, {"c", "d"}}
+	hardDeps := map[string][]string{"c": {"b"}, "d": {"a"}}
+
+	summary := r.Run(context.Background(), waves, hardDeps)
+	require.Error(t, summary.Err())
+	assert.Equal(t, 2, summary.Succeeded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+
+	statuses := map[string]Status{}
+	for _, res := range summary.Results {
+		statuses[res.App] = res.Status
+	}
+	assert.Equal(t, map[string]Status{
+		"a": StatusSucceeded,
+		"b": StatusFailed,
+		"c": StatusSkipped,
+		"d": StatusSucceeded,
+	}, statuses)
+
+	assert.Equal(t, 2, summary.Results[1].Attempts)
+
+	out, err := os.ReadFile(filepath.Join(logDir, "b.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "failing\nfailing\n", string(out))
+}
+
+func TestRunTimeout(t *testing.T) {
+	r := Runner{
+		Timeout: 50 * time.Millisecond,
+		Command: func(string, int) ([]string, error) {
+			return []string{"sleep", "5"}, nil
+		},
+	}
+
+	summary := r.Run(context.Background(), [][]string{{"a"}}, nil)
+	require.Len(t, summary.Results, 1)
+	assert.Equal(t, StatusFailed, summary.Results[0].Status)
+	assert.Contains(t, summary.Results[0].Error, "timeout")
+}
+
+func TestRunSkipsAppsWaitingForSlotWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := Runner{
+		Concurrency: 1,
+		Command: func(app string, _ int) ([]string, error) {
+			if app == "a" {
+				cancel()
+				return []string{"true"}, nil
+			}
+			return []string{"true"}, nil
+		},
+	}
+
+	summary := r.Run(ctx, [][]string{{"a", "b", "c"}}, nil)
+	require.Len(t, summary.Results, 3)
+	assert.Equal(t, StatusSkipped, summary.Results[1].Status)
+	assert.Equal(t, StatusSkipped, summary.Results[2].Status)
+	assert.Equal(t, 2, summary.Skipped)
+}
+
+func TestRunRejectsAppNamesThatAreNoLogFileNames(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "logs")
+	require.NoError(t, os.Mkdir(logDir, 0o755))
+
+	r := Runner{
+		LogDir: logDir,
+		Command: func(string, int) ([]string, error) {
+			return []string{"true"}, nil
+		},
+	}
+
+	summary := r.Run(context.Background(), [][]string{{"../escaped", "sub/app"}}, nil)
+	require.Len(t, summary.Results, 2)
+	for _, res := range summary.Results {
+		assert.Equal(t, StatusFailed, res.Status)
+		assert.Contains(t, res.Error, "can not be used as log file name")
+	}
+	assert.NoFileExists(t, filepath.Join(logDir, "..", "escaped.log"))
+}
+
+func TestResultJSONEncoding(t *testing.T) {
+	res := Result{App: "a", Wave: 1, Status: StatusSucceeded, Attempts: 1, Duration: 90 * time.Second}
+
+	buf, err := json.Marshal(&res)
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `"duration":"1m30s"`)
+
+	var decoded Result
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+	assert.Equal(t, res, decoded)
+
+	buf, err = json.Marshal(&Summary{Duration: time.Second, Results: []*Result{&res}})
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `"duration":"1s"`)
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// State records the results of the apps of a plan across multiple runs.
+type State struct {
+	// PlanHash is the hash of the plan the state belongs to, as returned
+	// by deps.Plan.Hash.
+	PlanHash string `json:"plan_hash"`
+	// Results contains the latest result of each app.
+	Results map[string]*Result `json:"results"`
+}
+
+// NewState returns an empty state for the plan with the given hash.
+func NewState(planHash string) *State {
+	return &State{
+		PlanHash: planHash,
+		Results:  map[string]*Result{},
+	}
+}
+
+// StateFromFile loads the state from path.
+// If the file does not exist, an empty state for planHash is returned. If
+// the state belongs to a plan with a different hash, e.g. because the plan
+// was recreated for other apps, an error is returned.
+func StateFromFile(path, planHash string) (*State, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return NewState(planHash), nil
+		}
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(buf, &state); err != nil {
+		return nil, fmt.Errorf("parsing state file failed: %w", err)
+	}
+
+	if state.PlanHash != planHash {
+		return nil, fmt.Errorf("state file %s belongs to a different plan, plan hash is %s, expecting %s",
+			path, state.PlanHash, planHash)
+	}
+
+	if state.Results == nil {
+		state.Results = map[string]*Result{}
+	}
+
+	return &state, nil
+}
+
+// Completed returns the apps that succeeded.
+func (s *State) Completed() map[string]struct{} {
+	res := map[string]struct{}{}
+	for app, r := range s.Results {
+		if r.Status == StatusSucceeded {
+			res[app] = struct{}{}
+		}
+	}
+
+	return res
+}
+
+// Record stores the result of an app.
+// Results of apps that already succeeded in a previous run are not
+// overwritten.
+func (s *State) Record(res *Result) {
+	if prev, exists := s.Results[res.App]; exists && prev.Status == StatusSucceeded {
+		return
+	}
+	s.Results[res.App] = res
+}
+
+// ToFile writes the state to path.
+// The file is replaced atomically, an interrupted write does not corrupt
+// an existing state file.
+func (s *State) ToFile(path string) error {
+	buf, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(buf); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,250 @@
+// Package systemd generates systemd unit drop-in files that order units by
+// the dependencies of their apps.
+package systemd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+	internalfs "github.com/simplesurance/dependencies-tool/v3/internal/fs"
+)
+
+// DropInName is the file name of the generated drop-ins.
+const DropInName = "10-deps.conf"
+
+const header = "# Generated by dependencies-tool, do not edit.\n"
+
+// UnitData are the fields that are available in the unit name template.
+type UnitData struct {
+	App          string
+	Distribution string
+}
+
+// DropIn is a generated drop-in file.
+type DropIn struct {
+	App  string
+	Unit string
+	// Path is the path of the file, relative to the directory that contains
+	// the drop-in directories: <UNIT>.d/10-deps.conf.
+	Path    string
+	Content string
+}
+
+// DropIns returns a drop-in for every app of the distribution, sorted by
+// unit name.
+// Hard dependencies are converted to Requires= and After= entries, soft
+// dependencies to Wants= entries. unitName is rendered for every app to get
+// the name of its unit.
+// If apps is not empty, only drop-ins for the given apps and their
+// dependencies are returned.
+// If a loop exist between hard dependencies a *CycleError is returned.
+func DropIns(composition *deps.Composition, distribution string, unitName *template.Template, apps ...string) ([]*DropIn, error) {
+	waves, err := composition.DependencyWaves(distribution, apps...)
+	if err != nil {
+		return nil, err
+	}
+
+	units := map[string]string{}
+	seen := map[string]string{}
+	for _, wave := range waves {
+		for _, app := range wave {
+			var buf bytes.Buffer
+			if err := unitName.Execute(&buf, &UnitData{App: app, Distribution: distribution}); err != nil {
+				return nil, fmt.Errorf("rendering unit name of %s failed: %w", app, err)
+			}
+
+			unit := buf.String()
+			if !internalfs.IsFileName(unit) {
+				return nil, fmt.Errorf("unit name %q of %s is invalid", unit, app)
+			}
+			if other, exists := seen[unit]; exists {
+				return nil, fmt.Errorf("the apps %s and %s have the same unit name %q", other, app, unit)
+			}
+			seen[unit] = app
+			units[app] = unit
+		}
+	}
+
+	res := make([]*DropIn, 0, len(units))
+	for app, unit := range units {
+		d := composition.Distribution[distribution][app]
+
+		var sb strings.Builder
+		sb.WriteString(header)
+		sb.WriteString("[Unit]\n")
+		writeEntries(&sb, "Requires", units, d.HardDeps)
+		writeEntries(&sb, "After", units, d.HardDeps)
+		writeEntries(&sb, "Wants", units, d.SoftDeps)
+
+		res = append(res, &DropIn{
+			App:     app,
+			Unit:    unit,
+			Path:    filepath.Join(unit+".d", DropInName),
+			Content: sb.String(),
+		})
+	}
+
+	slices.SortFunc(res, func(a, b *DropIn) int { return strings.Compare(a.Unit, b.Unit) })
+
+	return res, nil
+}
+
+func writeEntries(sb *strings.Builder, key string, units map[string]string, apps []string) {
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, units[app])
+	}
+	slices.Sort(names)
+
+	for _, n := range names {
+		fmt.Fprintf(sb, "%s=%s\n", key, n)
+	}
+}
+
+// Write writes the drop-ins to dir. Existing files are overwritten.
+func Write(dir string, dropIns []*DropIn) error {
+	for _, d := range dropIns {
+		path := filepath.Join(dir, d.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, []byte(d.Content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Difference is a drop-in file in a directory, that differs from the
+// generated one.
+type Difference struct {
+	// Path is the path of the drop-in, relative to the directory.
+	Path string
+	// Missing is true if the file does not exist.
+	Missing bool
+	// Unexpected is true if the file exists but no drop-in was generated
+	// for its unit.
+	Unexpected bool
+	// Removed are the lines of the existing file that are not in the
+	// generated drop-in.
+	Removed []string
+	// Added are the lines of the generated drop-in that are not in the
+	// existing file.
+	Added []string
+}
+
+func (d *Difference) String() string {
+	switch {
+	case d.Missing:
+		return d.Path + ": missing"
+	case d.Unexpected:
+		return d.Path + ": unexpected, no drop-in is generated for the unit"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(d.Path + ": differs")
+	for _, l := range d.Removed {
+		sb.WriteString("\n  - " + l)
+	}
+	for _, l := range d.Added {
+		sb.WriteString("\n  + " + l)
+	}
+	return sb.String()
+}
+
+// Verify compares the drop-ins with the files in dir. It returns a
+// Difference for every drop-in that is missing or has a different content and
+// for every file named 10-deps.conf in a drop-in directory of dir, that was
+// generated by dependencies-tool and does not belong to any of the drop-ins.
+// Files that do not start with the generated header, e.g. written by an
+// administrator, are ignored.
+func Verify(dir string, dropIns []*DropIn) ([]*Difference, error) {
+	var res []*Difference
+	expected := make(map[string]struct{}, len(dropIns))
+
+	for _, d := range dropIns {
+		expected[d.Path] = struct{}{}
+
+		content, err := os.ReadFile(filepath.Join(dir, d.Path))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				res = append(res, &Difference{Path: d.Path, Missing: true})
+				continue
+			}
+			return nil, err
+		}
+
+		if string(content) == d.Content {
+			continue
+		}
+
+		res = append(res, &Difference{
+			Path:    d.Path,
+			Removed: missingLines(string(content), d.Content),
+			Added:   missingLines(d.Content, string(content)),
+		})
+	}
+
+	existing, err := fs.Glob(os.DirFS(dir), "*.d/"+DropInName)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range existing {
+		path = filepath.FromSlash(path)
+		if _, exists := expected[path]; exists {
+			continue
+		}
+
+		generated, err := isGenerated(filepath.Join(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		if generated {
+			res = append(res, &Difference{Path: path, Unexpected: true})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b *Difference) int { return strings.Compare(a.Path, b.Path) })
+
+	return res, nil
+}
+
+// isGenerated returns true if the file at path starts with the header of
+// generated drop-ins.
+func isGenerated(path string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.HasPrefix(string(content), header), nil
+}
+
+// missingLines returns the lines of a that are not in b.
+func missingLines(a, b string) []string {
+	bLines := map[string]int{}
+	for _, l := range strings.Split(b, "\n") {
+		bLines[l]++
+	}
+
+	var res []string
+	for _, l := range strings.Split(a, "\n") {
+		if bLines[l] > 0 {
+			bLines[l]--
+			continue
+		}
+		res = append(res, l)
+	}
+
+	return res
+}
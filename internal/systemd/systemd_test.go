@@ -0,0 +1,78 @@
+package systemd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/dependencies-tool/v3/internal/deps"
+)
+
+func TestDropInsAndVerify(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("edge", "api", &deps.Dependencies{HardDeps: []string{"db"}, SoftDeps: []string{"metrics"}})
+	comp.Add("edge", "db", &deps.Dependencies{})
+	comp.Add("edge", "metrics", &deps.Dependencies{})
+
+	unitName := template.Must(template.New("").Parse("{{.Distribution}}-{{.App}}.service"))
+	dropIns, err := DropIns(comp, "edge", unitName)
+	require.NoError(t, err)
+	require.Len(t, dropIns, 3)
+
+	assert.Equal(t, filepath.Join("edge-api.service.d", DropInName), dropIns[0].Path)
+	assert.Equal(t, header+`[Unit]
+Requires=edge-db.service
+After=edge-db.service
+Wants=edge-metrics.service
+`, dropIns[0].Content)
+
+	dir := t.TempDir()
+	require.NoError(t, Write(dir, dropIns))
+
+	diffs, err := Verify(dir, dropIns)
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, dropIns[0].Path), []byte(header+"[Unit]\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(dir, dropIns[1].Path)))
+
+	diffs, err = Verify(dir, dropIns)
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, []string{
+		"Requires=edge-db.service",
+		"After=edge-db.service",
+		"Wants=edge-metrics.service",
+	}, diffs[0].Added)
+	assert.Empty(t, diffs[0].Removed)
+	assert.True(t, diffs[1].Missing)
+}
+
+func TestVerifyReportsOnlyGeneratedUnexpectedDropIns(t *testing.T) {
+	comp := deps.NewComposition()
+	comp.Add("edge", "api", &deps.Dependencies{})
+
+	unitName := template.Must(template.New("").Parse("{{.App}}.service"))
+	dropIns, err := DropIns(comp, "edge", unitName)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, Write(dir, dropIns))
+
+	writeFile := func(unit, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, unit+".d"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, unit+".d", DropInName), []byte(content), 0o644))
+	}
+	writeFile("removed.service", header+"[Unit]\nRequires=api.service\n")
+	writeFile("manual.service", "[Unit]\nAfter=network.target\n")
+
+	diffs, err := Verify(dir, dropIns)
+	require.NoError(t, err)
+	require.Len(t, diffs, 1)
+	assert.True(t, diffs[0].Unexpected)
+	assert.Equal(t, filepath.Join("removed.service.d", DropInName), diffs[0].Path)
+}
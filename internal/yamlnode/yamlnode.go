@@ -0,0 +1,53 @@
+// Package yamlnode provides helpers to modify YAML documents as yaml.Node
+// trees, which preserves the formatting and comments of unchanged parts.
+package yamlnode
+
+import (
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// String returns a scalar node with the string value v.
+func String(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+}
+
+// Bool returns a scalar node with the boolean value v.
+func Bool(v bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+}
+
+// Get returns the value of key in the mapping node m, nil if it does not
+// exist.
+func Get(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Set sets the value of key in the mapping node m. If the key does not
+// exist, it is appended.
+func Set(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, String(key), value)
+}
+
+// Delete removes key from the mapping node m.
+func Delete(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = slices.Delete(m.Content, i, i+2)
+			return
+		}
+	}
+}